@@ -0,0 +1,88 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Executor est un outil que le LLM peut appeler. Chaque outil vit dans son propre fichier
+// sous actions/executors et s'enregistre dans DefaultRegistry depuis son init().
+type Executor interface {
+	// Name est le nom de la fonction vu par le modèle (unique dans un Registry).
+	Name() string
+	// Description explique au modèle quand et comment utiliser l'outil.
+	Description() string
+	// Parameters est le JSON schema (type "object") des arguments.
+	Parameters() any
+	// Execute lance l'action avec les arguments JSON fournis par le modèle.
+	// Le résultat est sérialisé en JSON et renvoyé au modèle ; une erreur lui est renvoyée comme telle.
+	Execute(ctx context.Context, args json.RawMessage) (any, error)
+}
+
+// Registry associe les noms d'outils à leurs executors.
+type Registry struct {
+	mu        sync.RWMutex
+	executors map[string]Executor
+}
+
+func NewRegistry() *Registry {
+	return &Registry{executors: make(map[string]Executor)}
+}
+
+// DefaultRegistry reçoit les executors enregistrés par Register (typiquement depuis un init()).
+var DefaultRegistry = NewRegistry()
+
+// Register ajoute des executors à DefaultRegistry et panique en cas de doublon,
+// comme une erreur de programmation détectée au démarrage.
+func Register(executors ...Executor) {
+	for _, e := range executors {
+		if err := DefaultRegistry.Register(e); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Register ajoute un executor. Les noms doivent être uniques.
+func (r *Registry) Register(e Executor) error {
+	name := e.Name()
+	if name == "" {
+		return fmt.Errorf("executor %T sans nom", e)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.executors[name]; exists {
+		return fmt.Errorf("executor %q déjà enregistré", name)
+	}
+	r.executors[name] = e
+	return nil
+}
+
+// Get retourne l'executor nommé name.
+func (r *Registry) Get(name string) (Executor, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.executors[name]
+	return e, ok
+}
+
+// Executors retourne les executors triés par nom (ordre stable pour les requêtes au LLM).
+func (r *Registry) Executors() []Executor {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Executor, 0, len(r.executors))
+	for _, e := range r.executors {
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
+	return out
+}
+
+// Len retourne le nombre d'executors enregistrés.
+func (r *Registry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.executors)
+}
@@ -0,0 +1,73 @@
+package actions
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// noop crée un executor sans arguments qui ne fait rien.
+func noop(name string) Executor {
+	return NewExecutor(name, "Ne fait rien.", func(context.Context, struct{}) (any, error) { return nil, nil })
+}
+
+func TestRegistryRegister(t *testing.T) {
+	r := NewRegistry()
+	for _, name := range []string{"minuteur", "heure", "meteo"} {
+		if err := r.Register(noop(name)); err != nil {
+			t.Fatalf("%s : %v", name, err)
+		}
+	}
+
+	first, _ := r.Get("heure")
+	err := r.Register(noop("heure"))
+	if err == nil || !strings.Contains(err.Error(), `"heure" déjà enregistré`) {
+		t.Errorf("doublon : erreur %v", err)
+	}
+	if e, _ := r.Get("heure"); e != first {
+		t.Error("le doublon a remplacé l'executor déjà enregistré")
+	}
+	if err := r.Register(noop("")); err == nil {
+		t.Error("executor sans nom accepté")
+	}
+
+	if r.Len() != 3 {
+		t.Errorf("%d executors, attendu 3", r.Len())
+	}
+	var names []string
+	for _, e := range r.Executors() {
+		names = append(names, e.Name())
+	}
+	if strings.Join(names, ",") != "heure,meteo,minuteur" {
+		t.Errorf("executors %v, attendu triés par nom", names)
+	}
+}
+
+func TestRegistryGetUnknown(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register(noop("heure")); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"inconnu", "", "Heure"} {
+		if e, ok := r.Get(name); ok || e != nil {
+			t.Errorf("Get(%q) = %v, %v, attendu absent", name, e, ok)
+		}
+	}
+}
+
+func TestRegisterPanicsOnDuplicate(t *testing.T) {
+	prev := DefaultRegistry
+	DefaultRegistry = NewRegistry()
+	t.Cleanup(func() { DefaultRegistry = prev })
+
+	Register(noop("heure"), noop("meteo"))
+	defer func() {
+		if recover() == nil {
+			t.Error("pas de panique sur un doublon")
+		}
+		if DefaultRegistry.Len() != 2 {
+			t.Errorf("%d executors, attendu 2", DefaultRegistry.Len())
+		}
+	}()
+	Register(noop("heure"))
+}
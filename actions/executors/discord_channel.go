@@ -0,0 +1,36 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"tars/actions"
+)
+
+func init() {
+	actions.Register(actions.NewExecutor("createDiscordChannel", "Create a new channel on the Discord server", createDiscordChannel))
+}
+
+type discordChannelArgs struct {
+	ChannelName string `json:"channel_name" description:"Name of the channel to create" required:"true"`
+	ChannelType string `json:"channel_type,omitempty" enum:"text,voice"`
+}
+
+// createDiscordChannel crée un salon sur le serveur Discord. Simulé en attendant l'intégration discordgo.
+func createDiscordChannel(ctx context.Context, args discordChannelArgs) (any, error) {
+	name := strings.TrimSpace(args.ChannelName)
+	if name == "" {
+		return nil, fmt.Errorf("channel_name ne peut pas être vide")
+	}
+	if args.ChannelType == "" {
+		args.ChannelType = "text"
+	}
+	return map[string]interface{}{
+		"status":      "success",
+		"channelName": name,
+		"channelType": args.ChannelType,
+		"message":     "Canal simulé créé avec succès.",
+		"simulated":   true,
+	}, nil
+}
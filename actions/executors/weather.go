@@ -0,0 +1,31 @@
+package executors
+
+import (
+	"context"
+
+	"tars/actions"
+)
+
+func init() {
+	actions.Register(actions.NewExecutor("getCurrentWeather", "Get the current weather in a given location", currentWeather))
+}
+
+type weatherArgs struct {
+	Location string `json:"location" description:"The city and state, e.g. San Francisco, CA" required:"true"`
+	Unit     string `json:"unit,omitempty" enum:"celsius,fahrenheit"`
+}
+
+// currentWeather donne la météo actuelle d'une ville. Réponse simulée tant qu'aucune API météo n'est branchée.
+func currentWeather(ctx context.Context, args weatherArgs) (any, error) {
+	temperature, unit := 15, "celsius"
+	if args.Unit == "fahrenheit" {
+		temperature, unit = 59, "fahrenheit"
+	}
+	return map[string]interface{}{
+		"location":    args.Location,
+		"temperature": temperature,
+		"unit":        unit,
+		"description": "Partiellement nuageux",
+		"simulated":   true,
+	}, nil
+}
@@ -1,19 +1,35 @@
 package actions
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
+	"time"
+
+	"tars/config"
 
 	"github.com/sashabaranov/go-openai"
 )
 
+// ActionRouter exécute les tool calls du LLM via les executors de son Registry.
 type ActionRouter struct {
-	// Pourrait avoir des clients vers d'autres services plus tard
+	registry *Registry
+}
+
+// NewActionRouter crée un router sur registry (DefaultRegistry si nil).
+func NewActionRouter(registry *Registry) *ActionRouter {
+	if registry == nil {
+		registry = DefaultRegistry
+	}
+	return &ActionRouter{registry: registry}
 }
 
-func NewActionRouter() *ActionRouter {
-	return &ActionRouter{}
+// Registry retourne les outils disponibles, pour construire la liste envoyée au LLM.
+func (ar *ActionRouter) Registry() *Registry {
+	return ar.registry
 }
 
 type ToolResult struct {
@@ -21,9 +37,12 @@ type ToolResult struct {
 	Content    string `json:"content"` // Contenu JSON du résultat de l'outil
 }
 
-// ProcessToolCalls simule l'exécution d'outils et retourne leurs résultats.
-func (ar *ActionRouter) ProcessToolCalls(toolCalls []openai.ToolCall) []ToolResult {
-	var results []ToolResult
+// ProcessToolCalls exécute les tool calls d'un même tour en parallèle et retourne un résultat
+// par appel, dans l'ordre des appels (le modèle attend une réponse pour chaque tool_call_id).
+// Chaque appel est borné par config.ToolCallTimeoutMs, même si l'executor ignore son contexte ;
+// un executor qui panique donne un résultat d'erreur au lieu d'arrêter le programme.
+func (ar *ActionRouter) ProcessToolCalls(ctx context.Context, toolCalls []openai.ToolCall) []ToolResult {
+	results := make([]ToolResult, len(toolCalls))
 
 	if len(toolCalls) == 0 {
 		return results
@@ -31,56 +50,105 @@ func (ar *ActionRouter) ProcessToolCalls(toolCalls []openai.ToolCall) []ToolResu
 
 	log.Printf("ActionRouter: Reçu %d tool calls à traiter.", len(toolCalls))
 
-	for _, call := range toolCalls {
-		if call.Type != openai.ToolTypeFunction {
-			log.Printf("ActionRouter: Type d'outil non supporté: %s", call.Type)
-			continue
-		}
-
-		log.Printf("ActionRouter: Simulation de l'exécution de la fonction '%s' avec les arguments: %s", call.Function.Name, call.Function.Arguments)
-
-		// Simuler une réponse en fonction du nom de l'outil
-		var responseData interface{}
-		switch call.Function.Name {
-		case "getCurrentWeather":
-			// Simuler une réponse météo
-			// Pour un vrai outil, on lirait call.Function.Arguments
-			responseData = map[string]interface{}{
-				"location":    "Paris",
-				"temperature": "15",
-				"unit":        "celsius",
-				"description": "Partiellement nuageux",
+	var wg sync.WaitGroup
+	for i, call := range toolCalls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			callCtx, cancel := ctx, context.CancelFunc(func() {})
+			if config.ToolCallTimeoutMs > 0 {
+				callCtx, cancel = context.WithTimeout(ctx, time.Duration(config.ToolCallTimeoutMs)*time.Millisecond)
 			}
-		case "createDiscordChannel":
-			// Simuler la création d'un canal
-			responseData = map[string]interface{}{
-				"status":      "success",
-				"channelName": "simulated-channel-" + call.ID, // Utiliser call.ID pour un peu de variabilité
-				"message":     "Canal simulé créé avec succès.",
-			}
-		default:
-			responseData = map[string]interface{}{
-				"status":  "error",
-				"message": fmt.Sprintf("Outil '%s' non reconnu ou simulation non implémentée.", call.Function.Name),
+			defer cancel()
+			results[i] = ar.runToolCall(callCtx, call)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// runToolCall exécute call dans sa propre goroutine et rend la main dès que ctx expire :
+// un executor qui ignore son contexte continue en arrière-plan mais ne bloque plus le tour.
+func (ar *ActionRouter) runToolCall(ctx context.Context, call openai.ToolCall) ToolResult {
+	done := make(chan ToolResult, 1) // Tamponné : l'executor abandonné ne bloque pas en écrivant
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("ActionRouter: Panique de l'outil '%s' (ID: %s): %v", call.Function.Name, call.ID, r)
+				done <- errorResult(call.ID, fmt.Sprintf("tool panicked: %v", r))
 			}
-		}
+		}()
+		done <- ar.processToolCall(ctx, call)
+	}()
 
-		// Le LLM attend des résultats sous forme de string JSON
-		jsonResult, err := json.Marshal(responseData)
-		if err != nil {
-			log.Printf("ActionRouter: Erreur de marshalling du résultat JSON pour l'outil %s: %v", call.Function.Name, err)
-			results = append(results, ToolResult{
-				ToolCallID: call.ID,
-				Content:    `{"error": "failed to serialize result"}`,
-			})
-			continue
+	select {
+	case res := <-done:
+		return res
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			log.Printf("ActionRouter: Outil '%s' (ID: %s) abandonné après le timeout.", call.Function.Name, call.ID)
+			return errorResult(call.ID, "tool call timed out")
 		}
+		return errorResult(call.ID, ctx.Err().Error())
+	}
+}
 
-		log.Printf("ActionRouter: Résultat pour l'outil '%s' (ID: %s): %s", call.Function.Name, call.ID, string(jsonResult))
-		results = append(results, ToolResult{
-			ToolCallID: call.ID,
-			Content:    string(jsonResult),
-		})
+// processToolCall exécute un tool call via l'executor correspondant et retourne son résultat.
+// Les erreurs sont renvoyées au modèle sous forme {"error": "..."} pour qu'il puisse réagir.
+func (ar *ActionRouter) processToolCall(ctx context.Context, call openai.ToolCall) ToolResult {
+	if call.Type != openai.ToolTypeFunction {
+		log.Printf("ActionRouter: Type d'outil non supporté: %s", call.Type)
+		return errorResult(call.ID, fmt.Sprintf("unsupported tool type %q", call.Type))
 	}
-	return results
+
+	executor, ok := ar.registry.Get(call.Function.Name)
+	if !ok {
+		log.Printf("ActionRouter: Outil '%s' non reconnu.", call.Function.Name)
+		return errorResult(call.ID, fmt.Sprintf("unknown tool %q", call.Function.Name))
+	}
+
+	args := json.RawMessage(call.Function.Arguments)
+	if len(args) == 0 {
+		args = json.RawMessage("{}")
+	}
+
+	log.Printf("ActionRouter: Exécution de la fonction '%s' avec les arguments: %s", call.Function.Name, call.Function.Arguments)
+	start := time.Now()
+	responseData, err := executor.Execute(ctx, args)
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		log.Printf("ActionRouter: Arguments invalides pour '%s' (ID: %s): %v", call.Function.Name, call.ID, err)
+		content, _ := json.Marshal(struct {
+			Error   string       `json:"error"`
+			Details []FieldError `json:"details"`
+		}{"invalid_arguments", validationErr.Errors})
+		return ToolResult{ToolCallID: call.ID, Content: string(content)}
+	}
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+		log.Printf("ActionRouter: Outil '%s' (ID: %s) interrompu après %v.", call.Function.Name, call.ID, time.Since(start))
+		return errorResult(call.ID, "tool call timed out")
+	}
+	if err != nil {
+		log.Printf("ActionRouter: Erreur de l'outil '%s' (ID: %s): %v", call.Function.Name, call.ID, err)
+		return errorResult(call.ID, err.Error())
+	}
+
+	// Le LLM attend des résultats sous forme de string JSON
+	jsonResult, err := json.Marshal(responseData)
+	if err != nil {
+		log.Printf("ActionRouter: Erreur de marshalling du résultat JSON pour l'outil %s: %v", call.Function.Name, err)
+		return errorResult(call.ID, "failed to serialize result")
+	}
+
+	log.Printf("ActionRouter: Résultat pour l'outil '%s' (ID: %s) en %v: %s", call.Function.Name, call.ID, time.Since(start), string(jsonResult))
+	return ToolResult{
+		ToolCallID: call.ID,
+		Content:    string(jsonResult),
+	}
+}
+
+// errorResult construit le résultat renvoyé au modèle quand un outil échoue.
+func errorResult(toolCallID, message string) ToolResult {
+	content, _ := json.Marshal(map[string]string{"error": message})
+	return ToolResult{ToolCallID: toolCallID, Content: string(content)}
 }
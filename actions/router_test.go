@@ -0,0 +1,58 @@
+package actions
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"tars/config"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestProcessToolCallsRecoversAndTimesOut(t *testing.T) {
+	defer func(ms int) { config.ToolCallTimeoutMs = ms }(config.ToolCallTimeoutMs)
+	config.ToolCallTimeoutMs = 50
+
+	block := make(chan struct{})
+	defer close(block)
+
+	registry := NewRegistry()
+	for _, e := range []Executor{
+		NewExecutor("ok", "Répond ok.", func(context.Context, struct{}) (any, error) { return "ok", nil }),
+		NewExecutor("panic", "Panique.", func(context.Context, struct{}) (any, error) { panic("boum") }),
+		NewExecutor("slow", "Attend l'annulation.", func(ctx context.Context, _ struct{}) (any, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}),
+		NewExecutor("stuck", "Ignore son contexte.", func(context.Context, struct{}) (any, error) {
+			<-block
+			return "trop tard", nil
+		}),
+	} {
+		if err := registry.Register(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var calls []openai.ToolCall
+	for _, name := range []string{"ok", "panic", "slow", "stuck"} {
+		calls = append(calls, openai.ToolCall{ID: "call_" + name, Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: name}})
+	}
+	start := time.Now()
+	results := NewActionRouter(registry).ProcessToolCalls(context.Background(), calls)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("tour d'outils de %v malgré le timeout", elapsed)
+	}
+
+	want := []string{`"ok"`, "tool panicked: boum", "tool call timed out", "tool call timed out"}
+	for i, res := range results {
+		if res.ToolCallID != calls[i].ID {
+			t.Errorf("résultat %d : ID %q, attendu %q", i, res.ToolCallID, calls[i].ID)
+		}
+		if !strings.Contains(res.Content, want[i]) {
+			t.Errorf("résultat %d : %s, attendu %q", i, res.Content, want[i])
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema est le sous-ensemble de JSON Schema utilisé pour décrire les arguments d'un outil.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"` // false ou *Schema
+
+	nullable bool // Champ pointeur : null est accepté à la validation
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// SchemaFor génère le schéma des arguments à partir d'une struct Go.
+// Les noms viennent des tags json ; les tags `description:"..."`, `enum:"a,b"` (appliqué aux
+// éléments pour une slice) et `required:"true"` complètent la description. Les structs imbriquées, slices, maps,
+// pointeurs (optionnels) et time.Time (chaîne RFC 3339) sont supportés.
+func SchemaFor(v any) *Schema {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return &Schema{Type: "object", Properties: map[string]*Schema{}}
+	}
+	return schemaForType(t, map[reflect.Type]bool{})
+}
+
+func schemaForType(t reflect.Type, visiting map[reflect.Type]bool) *Schema {
+	if t.Kind() == reflect.Pointer {
+		s := schemaForType(t.Elem(), visiting)
+		s.nullable = true
+		return s
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaForType(t.Elem(), visiting)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaForType(t.Elem(), visiting)}
+	case reflect.Struct:
+		if visiting[t] {
+			return &Schema{Type: "object"} // Type récursif : on n'en décrit pas l'intérieur
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: false}
+		addFields(s, t, visiting)
+		return s
+	default:
+		// interface{} et autres : n'importe quelle valeur JSON
+		return &Schema{}
+	}
+}
+
+// addFields ajoute les champs de la struct t (y compris ceux des structs embarquées) au schéma s.
+func addFields(s *Schema, t reflect.Type, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, ok := jsonFieldName(f)
+		if !ok {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addFields(s, ft, visiting)
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		fs := schemaForType(f.Type, visiting)
+		if desc := f.Tag.Get("description"); desc != "" {
+			fs.Description = desc
+		}
+		if enum := f.Tag.Get("enum"); enum != "" {
+			if fs.Type == "array" && fs.Items != nil {
+				fs.Items.Enum = parseEnum(enum, fs.Items.Type) // Valeurs permises pour chaque élément
+			} else {
+				fs.Enum = parseEnum(enum, fs.Type)
+			}
+		}
+		s.Properties[name] = fs
+		if req, _ := strconv.ParseBool(f.Tag.Get("required")); req {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// jsonFieldName retourne le nom JSON du champ ("" si le tag n'en donne pas), ou false si le champ est ignoré.
+func jsonFieldName(f reflect.StructField) (string, bool) {
+	if !f.IsExported() && !f.Anonymous {
+		return "", false
+	}
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	return name, true
+}
+
+// parseEnum convertit les valeurs du tag enum selon le type du champ.
+func parseEnum(tag, typ string) []any {
+	var out []any
+	for _, v := range strings.Split(tag, ",") {
+		v = strings.TrimSpace(v)
+		switch typ {
+		case "integer", "number":
+			if n, err := strconv.ParseFloat(v, 64); err == nil {
+				out = append(out, n)
+				continue
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(v); err == nil {
+				out = append(out, b)
+				continue
+			}
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// FieldError décrit un argument invalide. Field est le chemin JSON (ex. "attendees[2].email").
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError est retournée quand les arguments du modèle ne respectent pas le schéma de l'outil.
+// Elle lui est renvoyée telle quelle pour qu'il corrige son appel.
+type ValidationError struct {
+	Errors []FieldError `json:"details"`
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		parts[i] = fe.Field + ": " + fe.Message
+	}
+	return "arguments invalides: " + strings.Join(parts, "; ")
+}
+
+// DecodeArgs valide les arguments JSON du modèle contre le schéma de dst (un pointeur
+// vers une struct), puis les décode dans dst. Retourne un *ValidationError en cas d'écart.
+func DecodeArgs(raw json.RawMessage, dst any) error {
+	t := reflect.TypeOf(dst)
+	if t == nil || t.Kind() != reflect.Pointer {
+		return fmt.Errorf("DecodeArgs: dst doit être un pointeur, reçu %T", dst)
+	}
+	return decodeArgs(raw, schemaForType(t.Elem(), map[reflect.Type]bool{}), dst)
+}
+
+func decodeArgs(raw json.RawMessage, schema *Schema, dst any) error {
+	if len(raw) == 0 {
+		raw = json.RawMessage("{}")
+	}
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return &ValidationError{Errors: []FieldError{{Field: "$", Message: "JSON invalide: " + err.Error()}}}
+	}
+
+	var errs []FieldError
+	validate(schema, value, "", &errs)
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+
+	if err := json.Unmarshal(raw, dst); err != nil {
+		return &ValidationError{Errors: []FieldError{{Field: "$", Message: err.Error()}}}
+	}
+	return nil
+}
+
+// validate vérifie value (décodée par encoding/json) contre s et accumule les erreurs dans errs.
+func validate(s *Schema, value any, path string, errs *[]FieldError) {
+	fail := func(format string, args ...any) {
+		field := path
+		if field == "" {
+			field = "$"
+		}
+		*errs = append(*errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if value == nil {
+		if !s.nullable && s.Type != "" {
+			fail("null non autorisé, %s attendu", s.Type)
+		}
+		return
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			fail("objet attendu, reçu %s", jsonKind(value))
+			return
+		}
+		for _, name := range s.Required {
+			if _, present := obj[name]; !present {
+				*errs = append(*errs, FieldError{Field: joinPath(path, name), Message: "champ requis"})
+			}
+		}
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys) // Erreurs dans un ordre stable
+		for _, k := range keys {
+			if prop, ok := s.Properties[k]; ok {
+				validate(prop, obj[k], joinPath(path, k), errs)
+				continue
+			}
+			switch extra := s.AdditionalProperties.(type) {
+			case *Schema:
+				validate(extra, obj[k], joinPath(path, k), errs)
+			case bool:
+				if !extra {
+					*errs = append(*errs, FieldError{Field: joinPath(path, k), Message: "champ inconnu"})
+				}
+			}
+		}
+	case "array":
+		arr, ok := value.([]any)
+		if !ok {
+			fail("tableau attendu, reçu %s", jsonKind(value))
+			return
+		}
+		if s.Items != nil {
+			for i, item := range arr {
+				validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			fail("chaîne attendue, reçu %s", jsonKind(value))
+			return
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				fail("date RFC 3339 attendue (ex. 2006-01-02T15:04:05Z07:00), reçu %q", str)
+				return
+			}
+		}
+	case "integer":
+		n, ok := value.(float64)
+		if !ok || n != math.Trunc(n) {
+			fail("entier attendu, reçu %s", jsonKind(value))
+			return
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			fail("nombre attendu, reçu %s", jsonKind(value))
+			return
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("booléen attendu, reçu %s", jsonKind(value))
+			return
+		}
+	}
+
+	if len(s.Enum) > 0 && reflect.TypeOf(value).Comparable() && !slices.Contains(s.Enum, value) {
+		fail("valeur %v non autorisée, valeurs possibles: %v", value, s.Enum)
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func jsonKind(v any) string {
+	switch v := v.(type) {
+	case map[string]any:
+		return "un objet"
+	case []any:
+		return "un tableau"
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,182 @@
+package actions
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+type testAudit struct {
+	CreatedBy string `json:"created_by"`
+}
+
+type testAddress struct {
+	Street string `json:"street" required:"true"`
+	City   string `json:"city"`
+}
+
+type testAttendee struct {
+	Name  string `json:"name"`
+	Email string `json:"email" required:"true"`
+}
+
+// testNode est récursif : ses enfants sont décrits comme de simples objets.
+type testNode struct {
+	Name     string     `json:"name"`
+	Children []testNode `json:"children"`
+}
+
+type testMeeting struct {
+	testAudit
+	Title     string         `json:"title" required:"true" description:"Titre de la réunion"`
+	Start     time.Time      `json:"start" required:"true"`
+	End       *time.Time     `json:"end"`
+	Room      *testAddress   `json:"room"`
+	Attendees []testAttendee `json:"attendees"`
+	Tags      []string       `json:"tags" enum:"travail,perso"`
+	Priority  int            `json:"priority" enum:"1,2,3"`
+	Hours     float64        `json:"hours"`
+	Labels    map[string]int `json:"labels"`
+	Tree      testNode       `json:"tree"`
+	Skipped   string         `json:"-"`
+	internal  string
+}
+
+func TestSchemaFor(t *testing.T) {
+	s := SchemaFor(testMeeting{})
+
+	var names []string
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	want := []string{"attendees", "created_by", "end", "hours", "labels", "priority", "room", "start", "tags", "title", "tree"}
+	if !slices.Equal(names, want) {
+		t.Errorf("propriétés %v, attendu %v", names, want)
+	}
+	if !slices.Equal(s.Required, []string{"title", "start"}) {
+		t.Errorf("required %v", s.Required)
+	}
+	if s.AdditionalProperties != false {
+		t.Errorf("additionalProperties %v, attendu false", s.AdditionalProperties)
+	}
+
+	p := s.Properties
+	if p["title"].Description != "Titre de la réunion" {
+		t.Errorf("description %q", p["title"].Description)
+	}
+	if p["start"].Type != "string" || p["start"].Format != "date-time" || p["start"].nullable {
+		t.Errorf("start : %+v", p["start"])
+	}
+	if p["end"].Format != "date-time" || !p["end"].nullable {
+		t.Errorf("end : %+v", p["end"])
+	}
+	if room := p["room"]; room.Type != "object" || !room.nullable || !slices.Equal(room.Required, []string{"street"}) {
+		t.Errorf("room : %+v", room)
+	}
+	if items := p["attendees"].Items; items == nil || items.Properties["email"] == nil {
+		t.Errorf("attendees : %+v", p["attendees"])
+	}
+	if p["tags"].Enum != nil || !reflect.DeepEqual(p["tags"].Items.Enum, []any{"travail", "perso"}) {
+		t.Errorf("tags : enum %v, items.enum %v", p["tags"].Enum, p["tags"].Items.Enum)
+	}
+	if p["priority"].Type != "integer" || !reflect.DeepEqual(p["priority"].Enum, []any{1.0, 2.0, 3.0}) {
+		t.Errorf("priority : %+v", p["priority"])
+	}
+	if p["hours"].Type != "number" {
+		t.Errorf("hours : %+v", p["hours"])
+	}
+	if extra, ok := p["labels"].AdditionalProperties.(*Schema); !ok || extra.Type != "integer" {
+		t.Errorf("labels : additionalProperties %#v", p["labels"].AdditionalProperties)
+	}
+	if children := p["tree"].Properties["children"].Items; children.Type != "object" || children.Properties != nil {
+		t.Errorf("tree.children : %+v", children)
+	}
+
+	// Le schéma est sérialisable tel quel pour le LLM.
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var generic map[string]any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		t.Fatal(err)
+	}
+	tags := generic["properties"].(map[string]any)["tags"].(map[string]any)
+	if _, ok := tags["enum"]; ok {
+		t.Errorf("enum sur le tableau tags : %s", data)
+	}
+}
+
+func TestDecodeArgs(t *testing.T) {
+	const valid = `{"title":"Point","start":"2024-05-02T10:00:00+02:00"`
+	tests := []struct {
+		name string
+		args string
+		want []string // Chemins des FieldError, dans l'ordre
+	}{
+		{name: "minimal", args: valid + `}`},
+		{name: "complet", args: valid + `,"created_by":"tars","end":null,"room":{"street":"1 rue de la Paix"},
+			"attendees":[{"email":"a@b.c"}],"tags":["perso"],"priority":2,"hours":1.5,"labels":{"x":1},
+			"tree":{"name":"a","children":[{"name":"b","children":[]}]}}`},
+		{name: "pointeurs nuls", args: valid + `,"end":null,"room":null}`},
+		{name: "vide", args: ``, want: []string{"title", "start"}},
+		{name: "JSON invalide", args: `{"title":`, want: []string{"$"}},
+		{name: "pas un objet", args: `[]`, want: []string{"$"}},
+		{name: "champ requis nul", args: `{"title":null,"start":"2024-05-02T10:00:00Z"}`, want: []string{"title"}},
+		{name: "champ inconnu", args: valid + `,"lieu":"ici"}`, want: []string{"lieu"}},
+		{name: "champ inconnu imbriqué", args: valid + `,"room":{"street":"x","floor":2}}`, want: []string{"room.floor"}},
+		{name: "champ requis imbriqué", args: valid + `,"room":{"city":"Paris"}}`, want: []string{"room.street"}},
+		{name: "date invalide", args: `{"title":"Point","start":"demain"}`, want: []string{"start"}},
+		{name: "entier décimal", args: valid + `,"priority":1.5}`, want: []string{"priority"}},
+		{name: "entier hors enum", args: valid + `,"priority":4}`, want: []string{"priority"}},
+		{name: "nombre non entier", args: valid + `,"hours":0.25}`},
+		{name: "nombre en chaîne", args: valid + `,"hours":"2"}`, want: []string{"hours"}},
+		{name: "élément hors enum", args: valid + `,"tags":["travail","vacances"]}`, want: []string{"tags[1]"}},
+		{
+			name: "chemins dans un tableau",
+			args: valid + `,"attendees":[{"email":"a@b.c"},{"email":42},{"name":"Zoé"}]}`,
+			want: []string{"attendees[1].email", "attendees[2].email"},
+		},
+		{name: "valeur de map", args: valid + `,"labels":{"a":1,"b":1.5}}`, want: []string{"labels.b"}},
+		{name: "type récursif", args: valid + `,"tree":{"children":[1]}}`, want: []string{"tree.children[0]"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m testMeeting
+			err := DecodeArgs(json.RawMessage(tt.args), &m)
+			var verr *ValidationError
+			if err != nil && !errors.As(err, &verr) {
+				t.Fatalf("erreur %v, attendu *ValidationError", err)
+			}
+			var got []string
+			if verr != nil {
+				for _, fe := range verr.Errors {
+					got = append(got, fe.Field)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("erreurs sur %v, attendu %v (%v)", got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestDecodeArgsFills(t *testing.T) {
+	var m testMeeting
+	args := `{"title":"Point","start":"2024-05-02T10:00:00Z","room":{"street":"x"},"attendees":[{"email":"a@b.c"}]}`
+	if err := DecodeArgs(json.RawMessage(args), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Title != "Point" || !m.Start.Equal(time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)) ||
+		m.Room == nil || m.Room.Street != "x" || len(m.Attendees) != 1 || m.End != nil {
+		t.Errorf("décodage : %+v", m)
+	}
+	if err := DecodeArgs(json.RawMessage(args), m); err == nil {
+		t.Error("DecodeArgs accepte une destination qui n'est pas un pointeur")
+	}
+}
@@ -0,0 +1,39 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// typedExecutor est un Executor dont les arguments sont décrits par la struct A :
+// le schéma en est généré et les arguments du modèle y sont validés puis décodés.
+type typedExecutor[A any] struct {
+	name        string
+	description string
+	schema      *Schema
+	run         func(ctx context.Context, args A) (any, error)
+}
+
+// NewExecutor crée un Executor à partir d'une struct d'arguments A (voir SchemaFor pour les tags)
+// et de la fonction qui exécute l'action avec les arguments déjà validés.
+func NewExecutor[A any](name, description string, run func(ctx context.Context, args A) (any, error)) Executor {
+	var zero A
+	return &typedExecutor[A]{
+		name:        name,
+		description: description,
+		schema:      SchemaFor(zero),
+		run:         run,
+	}
+}
+
+func (e *typedExecutor[A]) Name() string        { return e.name }
+func (e *typedExecutor[A]) Description() string { return e.description }
+func (e *typedExecutor[A]) Parameters() any     { return e.schema }
+
+func (e *typedExecutor[A]) Execute(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args A
+	if err := decodeArgs(raw, e.schema, &args); err != nil {
+		return nil, err
+	}
+	return e.run(ctx, args)
+}
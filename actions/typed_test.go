@@ -0,0 +1,51 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type testTimerArgs struct {
+	Minutes int    `json:"minutes" required:"true"`
+	Label   string `json:"label"`
+}
+
+func TestTypedExecutorDecodeError(t *testing.T) {
+	var got *testTimerArgs
+	e := NewExecutor("minuteur", "Lance un minuteur.", func(_ context.Context, args testTimerArgs) (any, error) {
+		got = &args
+		return "ok", nil
+	})
+
+	tests := []struct {
+		name, raw string
+		field     string // Champ de la première erreur
+	}{
+		{"JSON invalide", `{"minutes":`, "$"},
+		{"pas un objet", `[5]`, "$"},
+		{"champ requis absent", `{"label":"thé"}`, "minutes"},
+		{"mauvais type", `{"minutes":"cinq"}`, "minutes"},
+	}
+	for _, tt := range tests {
+		got = nil
+		res, err := e.Execute(context.Background(), json.RawMessage(tt.raw))
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Errorf("%s : erreur %v (%T), attendu une ValidationError", tt.name, err, err)
+			continue
+		}
+		if len(verr.Errors) == 0 || verr.Errors[0].Field != tt.field {
+			t.Errorf("%s : erreurs %+v, attendu sur %q", tt.name, verr.Errors, tt.field)
+		}
+		if res != nil || got != nil {
+			t.Errorf("%s : l'action a été lancée avec des arguments invalides", tt.name)
+		}
+	}
+
+	res, err := e.Execute(context.Background(), json.RawMessage(`{"minutes":5,"label":"thé"}`))
+	if err != nil || res != "ok" || got == nil || *got != (testTimerArgs{Minutes: 5, Label: "thé"}) {
+		t.Errorf("arguments valides : %v, %v, %+v", res, err, got)
+	}
+}
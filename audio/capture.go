@@ -3,89 +3,162 @@ package audio
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"time"
 
-	// IMPORTANT: Pour utiliser les constantes partagées
+	"tars/audio/convert"
+
 	"github.com/gordonklaus/portaudio"
 )
 
+// AudioCapturer lit le micro et émet des frames MONO (ce qu'attend le VAD, voir vad.go) de
+// frameDurationMs exactement, à sampleRate :
+//   - un périphérique multi-canal est ouvert à son nombre de canaux et ramené au mono par downmix ;
+//   - un périphérique qui n'accepte pas sampleRate (beaucoup de micros USB et de périphériques ALSA
+//     n'ouvrent qu'à 44,1 ou 48 kHz) est ouvert à sa fréquence native et rééchantillonné au fil de l'eau.
 type AudioCapturer struct {
-	// sampleRate et channels sont déjà là, c'est bien
-	sampleRate      int
-	channels        int
-	frameDurationMs int // Cette valeur sera config.VADFrameDurationMs
+	sampleRate      int      // Fréquence du pipeline (VAD, STT)
+	deviceRate      int      // Fréquence d'ouverture du périphérique
+	channels        int      // Canaux ouverts sur le périphérique
+	downmix         *Downmix // Réduction au mono quand channels > 1
+	frameDurationMs int      // Cette valeur sera config.VADFrameDurationMs
 	outputChan      chan<- []int16
+	device          *portaudio.DeviceInfo // Périphérique de capture résolu à la création
 	stream          *portaudio.Stream
-	// isInitialized   bool // Non requis si Initialize/Terminate sont gérés en dehors
+
+	// État du callback PortAudio (une seule goroutine).
+	resampler *convert.Resampler // nil si deviceRate == sampleRate
+	mono      []int16            // Frame du périphérique après downmix (réutilisé)
+	pending   []int16            // Échantillons au sample rate du pipeline pas encore émis
 }
 
 // NewAudioCapturer: portaudio.Initialize() doit être appelé avant dans main.go
-func NewAudioCapturer(sampleRate, channels, frameDurationMs int, outputChan chan<- []int16) (*AudioCapturer, error) {
-	if channels != 1 {
-		// go-webrtcvad nécessite du mono. On pourrait ajouter une conversion ici si nécessaire.
-		log.Printf("TARS AudioCapturer: AVERTISSEMENT - Le VAD attend de l'audio MONO. La capture est configurée pour %d canaux. Assurez-vous que c'est intentionnel ou que la conversion est gérée.", channels)
-	}
+// device choisit le micro (config.InputDevice) : vide pour le périphérique par défaut,
+// un index ou une partie du nom (voir FindInputDevice et `tars devices`).
+// channels est le nombre de canaux ouverts sur le périphérique, 0 pour son maximum (config.CaptureChannels) ;
+// downmix dit comment les ramener au mono (config.CaptureDownmix, config.CaptureChannel).
+// deviceRate force la fréquence d'ouverture du périphérique (config.CaptureSampleRate) ; 0 choisit
+// sampleRate si le périphérique l'accepte, sinon sa fréquence par défaut.
+func NewAudioCapturer(device string, sampleRate, deviceRate, channels int, downmix Downmix, frameDurationMs int, outputChan chan<- []int16) (*AudioCapturer, error) {
 	if frameDurationMs != 10 && frameDurationMs != 20 && frameDurationMs != 30 {
 		log.Printf("TARS AudioCapturer: AVERTISSEMENT - VADFrameDurationMs (%dms) n'est pas une valeur VAD standard (10, 20, 30). Cela causera des erreurs dans le VADProcessor.", frameDurationMs)
 	}
 
-	return &AudioCapturer{
+	dev, err := FindInputDevice(device)
+	if err != nil {
+		return nil, err
+	}
+	if channels <= 0 {
+		channels = dev.MaxInputChannels
+	}
+	if dev.MaxInputChannels < channels {
+		return nil, fmt.Errorf("le périphérique %q n'a que %d canal(aux) d'entrée, %d demandé(s)", dev.Name, dev.MaxInputChannels, channels)
+	}
+	if downmix.Mode == DownmixSelect && (downmix.Channel < 0 || downmix.Channel >= channels) {
+		return nil, fmt.Errorf("canal de capture %d hors limites : %q est ouvert sur %d canal(aux) (0 à %d)", downmix.Channel, dev.Name, channels, channels-1)
+	}
+	log.Printf("TARS AudioCapturer: Périphérique d'entrée [%d] %s (%s), %d canal(aux).", dev.Index, dev.Name, hostAPIName(dev), channels)
+	if channels > 1 {
+		log.Printf("TARS AudioCapturer: Downmix %s vers mono.", downmix.Mode)
+	}
+
+	ac := &AudioCapturer{
 		sampleRate:      sampleRate,
+		deviceRate:      deviceRate,
 		channels:        channels,
+		downmix:         &downmix,
 		frameDurationMs: frameDurationMs,
 		outputChan:      outputChan,
-	}, nil
+		device:          dev,
+	}
+	if ac.deviceRate <= 0 {
+		ac.deviceRate = sampleRate
+		if err := portaudio.IsFormatSupported(ac.streamParams(), func([]int16) {}); err != nil {
+			ac.deviceRate = int(dev.DefaultSampleRate)
+			log.Printf("TARS AudioCapturer: %q n'accepte pas %d Hz (%v), ouverture à %d Hz.", dev.Name, sampleRate, err, ac.deviceRate)
+		}
+	}
+	if ac.deviceRate != sampleRate {
+		log.Printf("TARS AudioCapturer: Rééchantillonnage %d Hz → %d Hz.", ac.deviceRate, sampleRate)
+		ac.resampler = convert.NewResampler(ac.deviceRate, sampleRate, 1)
+	}
+	return ac, nil
 }
 
-func (ac *AudioCapturer) Start(ctx context.Context) {
-	// portaudio.Terminate() doit être appelé dans main.go via defer
+// streamParams décrit le flux d'entrée : deviceRate, channels, et des buffers de frameDurationMs.
+func (ac *AudioCapturer) streamParams() portaudio.StreamParameters {
+	params := portaudio.LowLatencyParameters(ac.device, nil) // Micro seul, pas de sortie
+	params.Input.Channels = ac.channels
+	params.SampleRate = float64(ac.deviceRate)
+	params.FramesPerBuffer = ac.deviceRate * ac.frameDurationMs / 1000 // frames per buffer (samples per channel per callback)
+	return params
+}
+
+// frameSize est le nombre d'échantillons mono d'une frame émise vers le VAD.
+func (ac *AudioCapturer) frameSize() int {
+	return ac.sampleRate * ac.frameDurationMs / 1000
+}
+
+// process ramène une frame du périphérique au format du pipeline (mono, sampleRate) et
+// retourne les frames complètes de frameDurationMs ; le reste attend le callback suivant.
+func (ac *AudioCapturer) process(in []int16) [][]int16 {
+	frames := len(in) / ac.channels
+	if cap(ac.mono) < frames {
+		ac.mono = make([]int16, frames)
+	}
+	mono := ac.mono[:frames]
+	ac.downmix.Apply(in, ac.channels, mono)
+	if ac.resampler != nil {
+		mono = convert.Float32ToInt16(ac.resampler.Process(convert.Int16ToFloat32(mono)))
+	}
+	ac.pending = append(ac.pending, mono...)
+
+	size := ac.frameSize()
+	var out [][]int16
+	off := 0
+	for ; len(ac.pending)-off >= size; off += size {
+		// Copie : la frame part sur le canal alors que pending est réutilisé.
+		out = append(out, append([]int16(nil), ac.pending[off:off+size]...))
+	}
+	n := copy(ac.pending, ac.pending[off:])
+	ac.pending = ac.pending[:n]
+	return out
+}
 
-	framesPerBuffer := int(float64(ac.sampleRate) * float64(ac.frameDurationMs) / 1000.0)
-	// buffer pour portaudio, taille totale (samples * cannaux)
-	// portaudioCallbackBuffer := make([]int16, framesPerBuffer*ac.channels) // PAS UTILISÉ DIRECTEMENT DANS OpenDefaultStream avec callback
+// Start implémente AudioSource : capture jusqu'à l'annulation de ctx. Une erreur n'est
+// retournée que si le flux PortAudio n'a pas pu être ouvert ou démarré.
+func (ac *AudioCapturer) Start(ctx context.Context) error {
+	// portaudio.Terminate() doit être appelé dans main.go via defer
 
 	var err error
-	ac.stream, err = portaudio.OpenDefaultStream(
-		ac.channels, // input channels
-		0,           // output channels (mic only)
-		float64(ac.sampleRate),
-		framesPerBuffer, // frames per buffer (samples per channel per callback)
-		func(in []int16) { // `in` aura une taille de `framesPerBuffer * ac.channels`
-			// Si channels > 1, il faudrait ici extraire le premier canal ou moyenner pour obtenir du mono.
-			// Pour l'instant, on assume channels = 1, donc len(in) == framesPerBuffer.
-			// Si config.Channels était > 1, il faudrait une conversion :
-			// monoFrame := make([]int16, framesPerBuffer)
-			// for i := 0; i < framesPerBuffer; i++ {
-			// 	monoFrame[i] = in[i*ac.channels] // Prendre le premier canal
-			// }
-
-			frameCopy := make([]int16, len(in)) // Si mono, len(in) == framesPerBuffer
-			copy(frameCopy, in)
-
-			select {
-			case ac.outputChan <- frameCopy:
-			case <-time.After(15 * time.Millisecond): // Timeout généreux basé sur frameDuration
-				log.Println("TARS AudioCapturer: Timeout envoi frame audio vers VAD channel")
-			case <-ctx.Done():
-				return // Contexte annulé
+	ac.stream, err = portaudio.OpenStream(
+		ac.streamParams(),
+		func(in []int16) { // `in` : FramesPerBuffer frames de ac.channels canaux à deviceRate
+			for _, frame := range ac.process(in) {
+				select {
+				case ac.outputChan <- frame:
+				case <-time.After(15 * time.Millisecond): // Timeout généreux basé sur frameDuration
+					log.Println("TARS AudioCapturer: Timeout envoi frame audio vers VAD channel")
+				case <-ctx.Done():
+					return // Contexte annulé
+				}
 			}
 		},
 	)
 	if err != nil {
-		log.Printf("TARS AudioCapturer: Erreur ouverture flux PortAudio: %v. Essayez de spécifier un périphérique.", err)
-		// Lister les périphériques: devices, _ := portaudio.Devices()...
 		close(ac.outputChan) // Fermer pour signaler l'échec
-		return
+		return fmt.Errorf("ouverture flux PortAudio sur %q (%d Hz, %d canal(aux)): %w. "+
+			"Choisissez un autre périphérique avec InputDevice (liste: `tars devices`) ou une autre fréquence avec CaptureSampleRate",
+			ac.device.Name, ac.deviceRate, ac.channels, err)
 	}
 	// defer ac.stream.Close() // Sera fait après Stop()
 
 	err = ac.stream.Start()
 	if err != nil {
-		log.Printf("TARS AudioCapturer: Erreur démarrage flux PortAudio: %v", err)
 		ac.stream.Close() // Fermer si Start échoue
 		close(ac.outputChan)
-		return
+		return fmt.Errorf("démarrage flux PortAudio: %w", err)
 	}
 	log.Println("TARS AudioCapturer: Capture audio démarrée...")
 
@@ -100,4 +173,5 @@ func (ac *AudioCapturer) Start(ctx context.Context) {
 	}
 	close(ac.outputChan) // Important de fermer le canal quand la capture est finie
 	log.Println("TARS AudioCapturer: Capture audio terminée et canal de sortie fermé.")
+	return nil
 }
@@ -0,0 +1,72 @@
+package audio
+
+import (
+	"math"
+	"testing"
+
+	"tars/audio/convert"
+)
+
+// newTestCapturer crée un AudioCapturer sans périphérique, pour tester process seul.
+func newTestCapturer(deviceRate, channels, frameDurationMs int) *AudioCapturer {
+	ac := &AudioCapturer{
+		sampleRate:      16000,
+		deviceRate:      deviceRate,
+		channels:        channels,
+		downmix:         &Downmix{Mode: DownmixAverage},
+		frameDurationMs: frameDurationMs,
+	}
+	if deviceRate != ac.sampleRate {
+		ac.resampler = convert.NewResampler(deviceRate, ac.sampleRate, 1)
+	}
+	return ac
+}
+
+func TestCaptureProcessResamplesAndFrames(t *testing.T) {
+	tests := []struct {
+		name            string
+		deviceRate      int
+		bufferFrames    int // Frames du périphérique par callback
+		frameDurationMs int
+	}{
+		{"48 kHz stéréo, callbacks de 20 ms", 48000, 960, 20},
+		{"44,1 kHz stéréo, callbacks de 10 ms", 44100, 441, 30},
+		{"16 kHz stéréo, sans rééchantillonnage", 16000, 160, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ac := newTestCapturer(tt.deviceRate, 2, tt.frameDurationMs)
+			// Une seconde de 440 Hz : 8000 à gauche, 4000 à droite, soit une amplitude de 6000 après la moyenne.
+			var frames [][]int16
+			in := make([]int16, 2*tt.bufferFrames)
+			for n := 0; n < tt.deviceRate; n += tt.bufferFrames {
+				for i := range tt.bufferFrames {
+					s := math.Sin(2 * math.Pi * 440 * float64(n+i) / float64(tt.deviceRate))
+					in[2*i], in[2*i+1] = int16(8000*s), int16(4000*s)
+				}
+				frames = append(frames, ac.process(in)...)
+			}
+
+			size := 16000 * tt.frameDurationMs / 1000
+			for i, f := range frames {
+				if len(f) != size {
+					t.Fatalf("frame %d : %d échantillons, attendu %d", i, len(f), size)
+				}
+			}
+			// Le resampler retient quelques échantillons : au plus une frame manque.
+			if total := len(frames)*size + len(ac.pending); total > 16000 || total < 16000-size {
+				t.Errorf("%d échantillons à 16 kHz pour une seconde d'audio", total)
+			}
+			for i, f := range frames[2:] { // Les premières frames contiennent la montée du filtre
+				var sum float64
+				for _, s := range f {
+					sum += float64(s) * float64(s)
+				}
+				if rms := math.Sqrt(sum / float64(len(f))); math.Abs(rms-6000/math.Sqrt2) > 6000/math.Sqrt2*0.05 {
+					t.Errorf("frame %d : RMS %.0f, attendu %.0f (moyenne des deux canaux)", i+2, rms, 6000/math.Sqrt2)
+					break
+				}
+			}
+		})
+	}
+}
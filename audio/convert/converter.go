@@ -0,0 +1,64 @@
+package convert
+
+// Converter convertit un flux PCM d'un format à un autre (type d'échantillon, canaux,
+// fréquence). Il garde l'état du resampler entre les appels : utiliser un Converter par flux.
+type Converter struct {
+	from, to  Format
+	resampler *Resampler
+}
+
+// NewConverter crée un convertisseur de from vers to.
+func NewConverter(from, to Format) *Converter {
+	c := &Converter{from: from, to: to}
+	if from.SampleRate != to.SampleRate {
+		// On rééchantillonne sur le plus petit nombre de canaux : moins de calcul.
+		c.resampler = NewResampler(from.SampleRate, to.SampleRate, min(from.Channels, to.Channels))
+	}
+	return c
+}
+
+// From retourne le format d'entrée.
+func (c *Converter) From() Format { return c.from }
+
+// To retourne le format de sortie.
+func (c *Converter) To() Format { return c.to }
+
+// Convert convertit data (frames complètes au format d'entrée) et retourne les bytes au format de sortie.
+func (c *Converter) Convert(data []byte) []byte {
+	if c.from == c.to {
+		return data
+	}
+	return Encode(c.process(Decode(data, c.from.Sample)), c.to.Sample)
+}
+
+// Flush retourne la fin du signal retenue par le resampler (à appeler en fin de flux).
+func (c *Converter) Flush() []byte {
+	if c.resampler == nil {
+		return nil
+	}
+	out := c.resampler.Flush()
+	if c.to.Channels > c.from.Channels {
+		out = Remix(out, c.from.Channels, c.to.Channels)
+	}
+	return Encode(out, c.to.Sample)
+}
+
+// Reset oublie l'état du resampler (flux interrompu).
+func (c *Converter) Reset() {
+	if c.resampler != nil {
+		c.resampler.Reset()
+	}
+}
+
+func (c *Converter) process(samples []float32) []float32 {
+	if c.to.Channels < c.from.Channels {
+		samples = Remix(samples, c.from.Channels, c.to.Channels)
+	}
+	if c.resampler != nil {
+		samples = c.resampler.Process(samples)
+	}
+	if c.to.Channels > c.from.Channels {
+		samples = Remix(samples, c.from.Channels, c.to.Channels)
+	}
+	return samples
+}
@@ -0,0 +1,78 @@
+// Package convert convertit du PCM entre formats : type d'échantillon (int16/float32),
+// nombre de canaux et fréquence d'échantillonnage. Tout est en Go pur, sans cgo.
+package convert
+
+import (
+	"fmt"
+	"time"
+)
+
+// SampleType est le codage d'un échantillon PCM (little-endian).
+type SampleType int
+
+const (
+	Int16   SampleType = iota // PCM signé 16 bits
+	Float32                   // IEEE 754 32 bits, dans [-1, 1]
+)
+
+// Size retourne la taille d'un échantillon en bytes.
+func (t SampleType) Size() int {
+	if t == Float32 {
+		return 4
+	}
+	return 2
+}
+
+func (t SampleType) String() string {
+	if t == Float32 {
+		return "float32"
+	}
+	return "int16"
+}
+
+// Format décrit un flux PCM entrelacé.
+type Format struct {
+	SampleRate int
+	Channels   int
+	Sample     SampleType
+}
+
+// BytesPerFrame retourne la taille d'une frame (un échantillon par canal).
+func (f Format) BytesPerFrame() int {
+	return f.Channels * f.Sample.Size()
+}
+
+// Duration convertit une taille en bytes en durée.
+func (f Format) Duration(n int) time.Duration {
+	bps := f.SampleRate * f.BytesPerFrame()
+	if bps <= 0 {
+		return 0
+	}
+	return time.Duration(n) * time.Second / time.Duration(bps)
+}
+
+// Bytes convertit une durée en taille, arrondie à la frame inférieure.
+func (f Format) Bytes(d time.Duration) int {
+	frames := int(d * time.Duration(f.SampleRate) / time.Second)
+	return frames * f.BytesPerFrame()
+}
+
+// Valid indique si le format est utilisable.
+func (f Format) Valid() bool {
+	return f.SampleRate > 0 && f.Channels > 0 && (f.Sample == Int16 || f.Sample == Float32)
+}
+
+func (f Format) String() string {
+	return fmt.Sprintf("%d Hz, %d canal(aux), %v", f.SampleRate, f.Channels, f.Sample)
+}
+
+// Chunk est un morceau de PCM accompagné de son format, tel qu'envoyé au player.
+type Chunk struct {
+	Data   []byte
+	Format Format
+}
+
+// Duration retourne la durée du morceau.
+func (c Chunk) Duration() time.Duration {
+	return c.Format.Duration(len(c.Data))
+}
@@ -0,0 +1,39 @@
+package convert
+
+// Remix change le nombre de canaux d'un signal entrelacé :
+//   - vers mono : moyenne des canaux ;
+//   - depuis mono : le canal est dupliqué ;
+//   - sinon : les canaux communs sont copiés, les canaux en plus reçoivent la moyenne des canaux d'entrée.
+func Remix(in []float32, inChannels, outChannels int) []float32 {
+	if inChannels == outChannels || inChannels <= 0 || outChannels <= 0 {
+		return in
+	}
+	frames := len(in) / inChannels
+	out := make([]float32, frames*outChannels)
+
+	for f := 0; f < frames; f++ {
+		src := in[f*inChannels : (f+1)*inChannels]
+		dst := out[f*outChannels : (f+1)*outChannels]
+
+		if inChannels == 1 {
+			for c := range dst {
+				dst[c] = src[0]
+			}
+			continue
+		}
+
+		var sum float32
+		for _, s := range src {
+			sum += s
+		}
+		mean := sum / float32(inChannels)
+		for c := range dst {
+			if outChannels > 1 && c < inChannels {
+				dst[c] = src[c]
+			} else {
+				dst[c] = mean
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,192 @@
+package convert
+
+import "math"
+
+const (
+	resampleTaps    = 32   // Taps par phase à ratio >= 1 (élargi d'autant en sous-échantillonnage)
+	resamplePhases  = 256  // Phases du banc de filtres, interpolées linéairement entre elles
+	resampleBeta    = 8.0  // Paramètre de la fenêtre de Kaiser (≈ 80 dB de réjection)
+	resampleRolloff = 0.94 // Coupure en fraction de la fréquence de Nyquist la plus basse
+)
+
+// Resampler convertit la fréquence d'échantillonnage d'un flux entrelacé par filtrage
+// polyphase (sinc fenêtré par Kaiser). Il accepte tout rapport de fréquences et garde
+// son état entre les appels : le flux peut être découpé arbitrairement.
+type Resampler struct {
+	inRate, outRate int
+	channels        int
+	// Avance dans le signal d'entrée par échantillon de sortie : step/den échantillons
+	// (inRate/outRate réduit). Les positions sont entières en 1/den d'échantillon, pour que
+	// les erreurs d'arrondi ne s'accumulent pas et que le découpage du flux soit sans effet.
+	step, den int
+
+	taps   int
+	filter [][]float32 // [phase][tap], resamplePhases+1 lignes
+
+	history [][]float32 // Par canal : échantillons d'entrée encore nécessaires
+	pos     int         // Position du prochain échantillon de sortie dans history, en 1/den d'échantillon
+}
+
+// NewResampler crée un resampler de inRate vers outRate pour channels canaux entrelacés.
+func NewResampler(inRate, outRate, channels int) *Resampler {
+	g := gcd(inRate, outRate)
+	r := &Resampler{
+		inRate:   inRate,
+		outRate:  outRate,
+		channels: channels,
+		step:     inRate / g,
+		den:      outRate / g,
+	}
+
+	// En sous-échantillonnage, la coupure descend sous la moitié de la fréquence de sortie :
+	// le noyau s'élargit d'autant pour garder la même raideur.
+	cutoff := 0.5 * resampleRolloff * math.Min(1, float64(outRate)/float64(inRate)) // cycles/échantillon d'entrée
+	r.taps = int(math.Ceil(resampleTaps * math.Max(1, float64(inRate)/float64(outRate))))
+	r.taps += r.taps % 2 // Pair : autant d'échantillons de chaque côté
+	r.filter = designFilter(r.taps, cutoff)
+
+	r.Reset()
+	return r
+}
+
+// designFilter calcule le banc de filtres : filter[p][j] est le coefficient appliqué à
+// l'échantillon j de la fenêtre quand la sortie tombe à p/resamplePhases après l'échantillon central.
+func designFilter(taps int, cutoff float64) [][]float32 {
+	half := taps / 2
+	filter := make([][]float32, resamplePhases+1)
+	for p := range filter {
+		frac := float64(p) / resamplePhases
+		row := make([]float32, taps)
+		var sum float64
+		coeffs := make([]float64, taps)
+		for j := 0; j < taps; j++ {
+			d := float64(j-half+1) - frac // Distance (en échantillons d'entrée) à la position de sortie
+			c := 2 * cutoff * sinc(2*cutoff*d) * kaiser(d/float64(half), resampleBeta)
+			coeffs[j] = c
+			sum += c
+		}
+		for j := range row {
+			row[j] = float32(coeffs[j] / sum) // Gain unitaire en continu pour chaque phase
+		}
+		filter[p] = row
+	}
+	return filter
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return max(a, 1)
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// kaiser évalue la fenêtre de Kaiser en x ∈ [-1, 1].
+func kaiser(x, beta float64) float64 {
+	if x < -1 || x > 1 {
+		return 0
+	}
+	return besselI0(beta*math.Sqrt(1-x*x)) / besselI0(beta)
+}
+
+// besselI0 est la fonction de Bessel modifiée de première espèce d'ordre 0 (série entière).
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; k < 50; k++ {
+		term *= (x / (2 * float64(k))) * (x / (2 * float64(k)))
+		sum += term
+		if term < sum*1e-12 {
+			break
+		}
+	}
+	return sum
+}
+
+// Reset oublie l'historique (début d'un nouveau flux).
+func (r *Resampler) Reset() {
+	half := r.taps / 2
+	r.history = make([][]float32, r.channels)
+	for c := range r.history {
+		// Amorce de silence : la première sortie est centrée sur le premier échantillon d'entrée.
+		r.history[c] = make([]float32, half-1, half-1+4096)
+	}
+	r.pos = (half - 1) * r.den
+}
+
+// Process rééchantillonne in (entrelacé) et retourne les échantillons de sortie disponibles.
+// Environ taps/2 échantillons d'entrée restent en attente jusqu'à l'appel suivant ou Flush.
+func (r *Resampler) Process(in []float32) []float32 {
+	if r.inRate == r.outRate {
+		return in
+	}
+	frames := len(in) / r.channels
+	for c := 0; c < r.channels; c++ {
+		h := r.history[c]
+		for f := 0; f < frames; f++ {
+			h = append(h, in[f*r.channels+c])
+		}
+		r.history[c] = h
+	}
+	return r.run()
+}
+
+// Flush retourne la fin du signal encore retenue dans l'historique, puis réinitialise le resampler.
+func (r *Resampler) Flush() []float32 {
+	if r.inRate == r.outRate {
+		return nil
+	}
+	half := r.taps / 2
+	for c := range r.history {
+		r.history[c] = append(r.history[c], make([]float32, half)...)
+	}
+	out := r.run()
+	r.Reset()
+	return out
+}
+
+// run produit toutes les sorties dont la fenêtre est complète, puis jette l'historique consommé.
+func (r *Resampler) run() []float32 {
+	half := r.taps / 2
+	available := len(r.history[0])
+
+	var out []float32
+	for {
+		i := r.pos / r.den
+		if i+half >= available {
+			break
+		}
+		frac := float64(r.pos%r.den) / float64(r.den) * resamplePhases
+		p := int(frac)
+		t := float32(frac - float64(p))
+		row0, row1 := r.filter[p], r.filter[p+1]
+		start := i - half + 1
+
+		for c := 0; c < r.channels; c++ {
+			window := r.history[c][start : start+r.taps]
+			var acc0, acc1 float32
+			for j, x := range window {
+				acc0 += row0[j] * x
+				acc1 += row1[j] * x
+			}
+			out = append(out, acc0+(acc1-acc0)*t)
+		}
+		r.pos += r.step
+	}
+
+	// Garder seulement ce dont les prochaines sorties ont besoin.
+	drop := r.pos/r.den - half + 1
+	if drop > 0 {
+		for c := range r.history {
+			n := copy(r.history[c], r.history[c][drop:])
+			r.history[c] = r.history[c][:n]
+		}
+		r.pos -= drop * r.den
+	}
+	return out
+}
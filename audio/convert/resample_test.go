@@ -0,0 +1,144 @@
+package convert
+
+import (
+	"math"
+	"testing"
+)
+
+// tone génère seconds secondes d'une sinusoïde de freq Hz et d'amplitude 0.5 à rate Hz (mono).
+func tone(freq float64, rate int, seconds float64) []float32 {
+	out := make([]float32, int(seconds*float64(rate)))
+	for i := range out {
+		out[i] = float32(0.5 * math.Sin(2*math.Pi*freq*float64(i)/float64(rate)))
+	}
+	return out
+}
+
+func rms(x []float32) float64 {
+	var sum float64
+	for _, s := range x {
+		sum += float64(s) * float64(s)
+	}
+	return math.Sqrt(sum / float64(len(x)))
+}
+
+// resampleAll rééchantillonne in en morceaux de chunk échantillons (0 : en une fois), Flush compris.
+func resampleAll(r *Resampler, in []float32, chunk int) []float32 {
+	if chunk <= 0 {
+		chunk = len(in)
+	}
+	var out []float32
+	for len(in) > 0 {
+		n := min(chunk, len(in))
+		out = append(out, r.Process(in[:n])...)
+		in = in[n:]
+	}
+	return append(out, r.Flush()...)
+}
+
+func TestResamplerLength(t *testing.T) {
+	rates := [][2]int{{44100, 16000}, {16000, 48000}, {48000, 16000}, {22050, 24000}, {24000, 22050}, {8000, 44100}, {44100, 48000}}
+	for _, rate := range rates {
+		in := make([]float32, rate[0]) // Une seconde
+		for _, chunk := range []int{0, 1, 160, 441, 1000} {
+			out := resampleAll(NewResampler(rate[0], rate[1], 1), in, chunk)
+			if len(out) != rate[1] {
+				t.Errorf("%d → %d Hz, morceaux de %d : %d échantillons pour une seconde, attendu %d", rate[0], rate[1], chunk, len(out), rate[1])
+			}
+		}
+	}
+}
+
+func TestResamplerChunkedMatchesOneShot(t *testing.T) {
+	for _, rate := range [][2]int{{48000, 16000}, {16000, 48000}, {44100, 16000}, {22050, 24000}} {
+		in := tone(440, rate[0], 0.5)
+		want := resampleAll(NewResampler(rate[0], rate[1], 1), in, 0)
+		for _, chunk := range []int{1, 7, 320, 1024} {
+			got := resampleAll(NewResampler(rate[0], rate[1], 1), in, chunk)
+			if len(got) != len(want) {
+				t.Fatalf("%d → %d Hz, morceaux de %d : %d échantillons, %d en une fois", rate[0], rate[1], chunk, len(got), len(want))
+			}
+			for i := range got {
+				if math.Abs(float64(got[i]-want[i])) > 1e-6 {
+					t.Fatalf("%d → %d Hz, morceaux de %d : échantillon %d = %v, %v en une fois", rate[0], rate[1], chunk, i, got[i], want[i])
+				}
+			}
+		}
+	}
+}
+
+func TestResamplerStereo(t *testing.T) {
+	// Canaux entrelacés traités indépendamment : gauche = ton, droite = silence.
+	left := tone(1000, 48000, 0.2)
+	in := make([]float32, 2*len(left))
+	for i, s := range left {
+		in[2*i] = s
+	}
+	out := resampleAll(NewResampler(48000, 16000, 2), in, 480)
+	if len(out) != 2*len(left)/3 {
+		t.Fatalf("%d échantillons, attendu %d", len(out), 2*len(left)/3)
+	}
+	var l, r []float32
+	for i := 0; i+1 < len(out); i += 2 {
+		l, r = append(l, out[i]), append(r, out[i+1])
+	}
+	if rms(r) > 1e-6 || rms(l) < 0.3 {
+		t.Errorf("RMS gauche %.3f, droite %.6f", rms(l), rms(r))
+	}
+}
+
+// gainDB mesure le gain d'un ton de freq Hz à travers un rééchantillonnage de inRate vers outRate,
+// hors des bords (amorce et fin du filtre).
+func gainDB(freq float64, inRate, outRate int) float64 {
+	in := tone(freq, inRate, 1)
+	out := resampleAll(NewResampler(inRate, outRate, 1), in, 0)
+	edge := outRate / 10
+	return 20 * math.Log10(rms(out[edge:len(out)-edge])/rms(in))
+}
+
+func TestResamplerPassband(t *testing.T) {
+	for _, tt := range []struct {
+		freq            float64
+		inRate, outRate int
+	}{
+		{1000, 48000, 16000},
+		{1000, 44100, 16000},
+		{3000, 48000, 16000},
+		{1000, 16000, 48000},
+		{5000, 22050, 24000},
+	} {
+		if g := gainDB(tt.freq, tt.inRate, tt.outRate); math.Abs(g) > 0.1 {
+			t.Errorf("%v Hz, %d → %d Hz : gain %.3f dB, attendu 0 ± 0.1 dB", tt.freq, tt.inRate, tt.outRate, g)
+		}
+	}
+}
+
+func TestResamplerStopband(t *testing.T) {
+	// Au-delà du Nyquist de sortie, un ton ne doit pas se replier dans la bande utile.
+	for _, tt := range []struct {
+		freq            float64
+		inRate, outRate int
+	}{
+		{9000, 48000, 16000},
+		{12000, 48000, 16000},
+		{20000, 44100, 16000},
+		{14000, 44100, 22050},
+	} {
+		if g := gainDB(tt.freq, tt.inRate, tt.outRate); g > -60 {
+			t.Errorf("%v Hz, %d → %d Hz : réjection %.1f dB, attendu moins de -60 dB", tt.freq, tt.inRate, tt.outRate, g)
+		}
+	}
+}
+
+func TestResamplerSameRate(t *testing.T) {
+	in := tone(440, 16000, 0.1)
+	out := resampleAll(NewResampler(16000, 16000, 1), in, 100)
+	if len(out) != len(in) {
+		t.Fatalf("%d échantillons, attendu %d", len(out), len(in))
+	}
+	for i := range in {
+		if out[i] != in[i] {
+			t.Fatalf("échantillon %d modifié", i)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package convert
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Int16ToFloat32 convertit des échantillons int16 en float32 dans [-1, 1).
+func Int16ToFloat32(in []int16) []float32 {
+	out := make([]float32, len(in))
+	for i, s := range in {
+		out[i] = float32(s) / 32768
+	}
+	return out
+}
+
+// Float32ToInt16 convertit des échantillons float32 en int16, en écrêtant hors de [-1, 1].
+func Float32ToInt16(in []float32) []int16 {
+	out := make([]int16, len(in))
+	for i, s := range in {
+		out[i] = floatToInt16(s)
+	}
+	return out
+}
+
+func floatToInt16(s float32) int16 {
+	v := math.Round(float64(s) * 32768)
+	if v > math.MaxInt16 {
+		return math.MaxInt16
+	}
+	if v < math.MinInt16 {
+		return math.MinInt16
+	}
+	return int16(v)
+}
+
+// BytesToInt16 décode du PCM 16 bits little-endian. Un octet final isolé est ignoré.
+func BytesToInt16(b []byte) []int16 {
+	out := make([]int16, len(b)/2)
+	for i := range out {
+		out[i] = int16(binary.LittleEndian.Uint16(b[2*i:]))
+	}
+	return out
+}
+
+// Int16ToBytes encode du PCM 16 bits en little-endian.
+func Int16ToBytes(in []int16) []byte {
+	out := make([]byte, len(in)*2)
+	for i, s := range in {
+		binary.LittleEndian.PutUint16(out[2*i:], uint16(s))
+	}
+	return out
+}
+
+// BytesToFloat32 décode du PCM float32 little-endian. Les octets finaux incomplets sont ignorés.
+func BytesToFloat32(b []byte) []float32 {
+	out := make([]float32, len(b)/4)
+	for i := range out {
+		out[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return out
+}
+
+// Float32ToBytes encode du PCM float32 en little-endian.
+func Float32ToBytes(in []float32) []byte {
+	out := make([]byte, len(in)*4)
+	for i, s := range in {
+		binary.LittleEndian.PutUint32(out[4*i:], math.Float32bits(s))
+	}
+	return out
+}
+
+// Decode convertit des bytes au type t en float32.
+func Decode(b []byte, t SampleType) []float32 {
+	if t == Float32 {
+		return BytesToFloat32(b)
+	}
+	out := make([]float32, len(b)/2)
+	for i := range out {
+		out[i] = float32(int16(binary.LittleEndian.Uint16(b[2*i:]))) / 32768
+	}
+	return out
+}
+
+// Encode convertit des float32 en bytes au type t.
+func Encode(in []float32, t SampleType) []byte {
+	if t == Float32 {
+		return Float32ToBytes(in)
+	}
+	out := make([]byte, len(in)*2)
+	for i, s := range in {
+		binary.LittleEndian.PutUint16(out[2*i:], uint16(floatToInt16(s)))
+	}
+	return out
+}
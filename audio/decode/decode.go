@@ -0,0 +1,52 @@
+// Package decode décode en PCM, au fil de la lecture, les formats audio compressés ou
+// encapsulés renvoyés par les backends TTS (MP3, Ogg Opus, WAV). Décodeurs en Go pur.
+package decode
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"tars/audio/convert"
+)
+
+// Codec identifie l'encodage d'un flux audio.
+type Codec string
+
+const (
+	PCM  Codec = "pcm"  // PCM brut, format connu à l'avance
+	MP3  Codec = "mp3"  // MPEG-1/2 Layer III
+	Opus Codec = "opus" // Opus encapsulé dans Ogg
+	WAV  Codec = "wav"  // RIFF/WAVE (PCM entier ou float)
+)
+
+// ParseCodec convertit un nom de format (config) en Codec.
+func ParseCodec(name string) (Codec, error) {
+	switch c := Codec(strings.ToLower(strings.TrimSpace(name))); c {
+	case PCM, MP3, Opus, WAV:
+		return c, nil
+	case "":
+		return PCM, nil
+	default:
+		return "", fmt.Errorf("format audio non supporté %q (attendu pcm, mp3, opus ou wav)", name)
+	}
+}
+
+// NewReader retourne un lecteur de PCM décodé depuis r et son format. Les en-têtes sont lus
+// immédiatement (l'appel bloque jusqu'à leur réception) ; l'audio est ensuite décodé au fur et
+// à mesure des lectures, pour que la lecture commence avant la fin du téléchargement.
+// pcmFormat n'est utilisé que pour le codec PCM.
+func NewReader(codec Codec, r io.Reader, pcmFormat convert.Format) (io.Reader, convert.Format, error) {
+	switch codec {
+	case PCM:
+		return r, pcmFormat, nil
+	case MP3:
+		return newMP3Reader(r)
+	case Opus:
+		return newOpusReader(r)
+	case WAV:
+		return newWAVReader(r)
+	default:
+		return nil, convert.Format{}, fmt.Errorf("codec inconnu %q", codec)
+	}
+}
@@ -0,0 +1,90 @@
+package decode
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"tars/audio/convert"
+)
+
+// Fixtures : speech.mp3 (MPEG-2 mono 22,05 kHz, 40 frames de 576 échantillons), tiny.ogg
+// (Opus mono, un paquet de 20 ms et 312 échantillons de pre-skip, repris de pion/opus) et
+// tone.wav (440 Hz, 24 kHz mono 16 bits, 2400 échantillons).
+var fixtures = []struct {
+	file    string
+	codec   Codec
+	format  convert.Format
+	samples int // Échantillons décodés par canal
+}{
+	// go-mp3 sort toujours du stéréo, même pour une source mono.
+	{"speech.mp3", MP3, convert.Format{SampleRate: 22050, Channels: 2, Sample: convert.Int16}, 40 * 576},
+	{"tiny.ogg", Opus, convert.Format{SampleRate: 48000, Channels: 1, Sample: convert.Int16}, 960 - 312},
+	{"tone.wav", WAV, convert.Format{SampleRate: 24000, Channels: 1, Sample: convert.Int16}, 2400},
+}
+
+func decodeAll(t *testing.T, codec Codec, data []byte) ([]byte, convert.Format, error) {
+	t.Helper()
+	r, format, err := NewReader(codec, bytes.NewReader(data), convert.Format{})
+	if err != nil {
+		return nil, format, err
+	}
+	pcm, err := io.ReadAll(r)
+	return pcm, format, err
+}
+
+func TestDecodeFixtures(t *testing.T) {
+	for _, fx := range fixtures {
+		t.Run(fx.file, func(t *testing.T) {
+			data, err := os.ReadFile("testdata/" + fx.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pcm, format, err := decodeAll(t, fx.codec, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != fx.format {
+				t.Errorf("format %v, attendu %v", format, fx.format)
+			}
+			if got := len(pcm) / format.BytesPerFrame(); got != fx.samples || len(pcm)%format.BytesPerFrame() != 0 {
+				t.Errorf("%d bytes (%d échantillons par canal), attendu %d échantillons", len(pcm), got, fx.samples)
+			}
+			if bytes.Count(pcm, []byte{0}) == len(pcm) {
+				t.Error("décodage silencieux")
+			}
+		})
+	}
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	for _, fx := range fixtures {
+		t.Run(fx.file, func(t *testing.T) {
+			data, err := os.ReadFile("testdata/" + fx.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			full, _, err := decodeAll(t, fx.codec, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// En-tête incomplet : erreur dès NewReader.
+			for _, n := range []int{0, 20} {
+				if _, _, err := NewReader(fx.codec, bytes.NewReader(data[:n]), convert.Format{}); err == nil {
+					t.Errorf("%d bytes : pas d'erreur sur un en-tête tronqué", n)
+				}
+			}
+
+			// Flux coupé en cours de route : le décodage s'arrête proprement sur un début de l'audio.
+			pcm, _, err := decodeAll(t, fx.codec, data[:len(data)/2+1])
+			if err != nil {
+				t.Fatalf("flux tronqué : %v", err)
+			}
+			if len(pcm) >= len(full) || !bytes.Equal(pcm, full[:len(pcm)]) {
+				t.Errorf("flux tronqué : %d bytes décodés sur %d, attendu un préfixe strict", len(pcm), len(full))
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package decode
+
+import (
+	"fmt"
+	"io"
+
+	"tars/audio/convert"
+
+	"github.com/hajimehoshi/go-mp3"
+)
+
+// newMP3Reader décode du MP3. go-mp3 produit toujours du 16 bits stéréo, même pour une source mono.
+func newMP3Reader(r io.Reader) (io.Reader, convert.Format, error) {
+	// r n'est pas un io.Seeker (réponse HTTP) : go-mp3 ne lit que la première frame ici.
+	dec, err := mp3.NewDecoder(r)
+	if err != nil {
+		return nil, convert.Format{}, fmt.Errorf("décodeur MP3: %w", err)
+	}
+	return dec, convert.Format{SampleRate: dec.SampleRate(), Channels: 2, Sample: convert.Int16}, nil
+}
@@ -0,0 +1,90 @@
+package decode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"tars/audio/convert"
+
+	"github.com/pion/opus"
+	"github.com/pion/opus/pkg/oggreader"
+)
+
+const (
+	opusSampleRate   = 48000 // Fréquence interne d'Opus, celle du pre-skip
+	opusMaxFrameSize = 5760  // 120 ms à 48 kHz, taille max d'un paquet par canal
+)
+
+// opusReader décode un flux Ogg Opus paquet par paquet.
+type opusReader struct {
+	ogg      *oggreader.OggReader
+	dec      opus.Decoder
+	channels int
+	preSkip  int // Échantillons (par canal) à jeter en début de flux, cf. RFC 7845
+	samples  []int16
+	buf      []byte // PCM décodé pas encore lu
+}
+
+func newOpusReader(r io.Reader) (io.Reader, convert.Format, error) {
+	ogg, header, err := oggreader.NewWith(r)
+	if err != nil {
+		return nil, convert.Format{}, fmt.Errorf("en-tête Ogg Opus: %w", err)
+	}
+	channels := int(header.Channels)
+	if channels < 1 || channels > 2 {
+		return nil, convert.Format{}, fmt.Errorf("Opus: %d canaux non supportés", channels)
+	}
+	dec, err := opus.NewDecoderWithOutput(opusSampleRate, channels)
+	if err != nil {
+		return nil, convert.Format{}, fmt.Errorf("décodeur Opus: %w", err)
+	}
+
+	or := &opusReader{
+		ogg:      ogg,
+		dec:      dec,
+		channels: channels,
+		preSkip:  int(header.PreSkip),
+		samples:  make([]int16, opusMaxFrameSize*channels),
+	}
+	return or, convert.Format{SampleRate: opusSampleRate, Channels: channels, Sample: convert.Int16}, nil
+}
+
+func (or *opusReader) Read(p []byte) (int, error) {
+	for len(or.buf) == 0 {
+		if err := or.decodeNext(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, or.buf)
+	or.buf = or.buf[n:]
+	return n, nil
+}
+
+// decodeNext décode le prochain paquet audio dans buf.
+func (or *opusReader) decodeNext() error {
+	packet, _, err := or.ogg.ParseNextPacket()
+	if err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return io.EOF // Flux coupé en fin de paquet : on s'arrête sur ce qui a été décodé
+		}
+		return err
+	}
+	if len(packet) == 0 || bytes.HasPrefix(packet, []byte("OpusTags")) {
+		return nil // Paquet de commentaires (2e en-tête)
+	}
+
+	n, err := or.dec.DecodeToInt16(packet, or.samples)
+	if err != nil {
+		return fmt.Errorf("décodage Opus: %w", err)
+	}
+	frame := or.samples[:n*or.channels]
+	if or.preSkip > 0 {
+		skip := min(or.preSkip, n)
+		frame = frame[skip*or.channels:]
+		or.preSkip -= skip
+	}
+	or.buf = append(or.buf[:0], convert.Int16ToBytes(frame)...)
+	return nil
+}
@@ -0,0 +1,20 @@
+package decode
+
+import (
+	"io"
+
+	"tars/audio/convert"
+	"tars/audio/wav"
+)
+
+// newWAVReader lit l'en-tête RIFF/WAVE et retourne un lecteur sur les échantillons, convertis
+// au format le plus proche du pipeline (voir wav.Format.PCMFormat). Les tailles de chunk d'un WAV
+// streamé sont souvent fausses (0 ou 0xFFFFFFFF) : les données sont alors lues jusqu'à la fin du flux.
+func newWAVReader(r io.Reader) (io.Reader, convert.Format, error) {
+	wr, err := wav.NewReader(r)
+	if err != nil {
+		return nil, convert.Format{}, err
+	}
+	pcm, format := wr.PCM()
+	return pcm, format, nil
+}
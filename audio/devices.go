@@ -0,0 +1,139 @@
+package audio
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gordonklaus/portaudio"
+)
+
+// Les fonctions de ce fichier supposent que portaudio.Initialize() a été appelé.
+
+// ListDevices retourne tous les périphériques audio vus par PortAudio, toutes API hôtes confondues.
+func ListDevices() ([]*portaudio.DeviceInfo, error) {
+	devices, err := portaudio.Devices()
+	if err != nil {
+		return nil, fmt.Errorf("énumération des périphériques PortAudio: %w", err)
+	}
+	return devices, nil
+}
+
+// PrintDevices écrit la liste des périphériques sous forme de tableau. Les périphériques
+// par défaut de l'API hôte par défaut sont marqués d'un « * » (entrée) ou d'un « > » (sortie).
+func PrintDevices(w io.Writer) error {
+	devices, err := ListDevices()
+	if err != nil {
+		return err
+	}
+	defIn, _ := portaudio.DefaultInputDevice()
+	defOut, _ := portaudio.DefaultOutputDevice()
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tINDEX\tNOM\tAPI\tENTRÉES\tSORTIES\tFRÉQUENCE")
+	for _, d := range devices {
+		mark := ""
+		if defIn != nil && d.Index == defIn.Index {
+			mark += "*"
+		}
+		if defOut != nil && d.Index == defOut.Index {
+			mark += ">"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%d\t%d\t%.0f Hz\n",
+			mark, d.Index, d.Name, hostAPIName(d), d.MaxInputChannels, d.MaxOutputChannels, d.DefaultSampleRate)
+	}
+	fmt.Fprintln(tw, "\n* entrée par défaut, > sortie par défaut")
+	return tw.Flush()
+}
+
+// FindInputDevice résout la sélection d'un périphérique de capture (config.InputDevice) :
+// vide pour le périphérique par défaut, un index, ou une partie du nom (insensible à la casse).
+func FindInputDevice(spec string) (*portaudio.DeviceInfo, error) {
+	return findDevice(spec, "d'entrée", portaudio.DefaultInputDevice,
+		func(d *portaudio.DeviceInfo) bool { return d.MaxInputChannels > 0 })
+}
+
+// FindOutputDevice résout la sélection d'un périphérique de lecture (config.OutputDevice),
+// selon les mêmes règles que FindInputDevice.
+func FindOutputDevice(spec string) (*portaudio.DeviceInfo, error) {
+	return findDevice(spec, "de sortie", portaudio.DefaultOutputDevice,
+		func(d *portaudio.DeviceInfo) bool { return d.MaxOutputChannels > 0 })
+}
+
+// findDevice cherche parmi les périphériques acceptés par usable. Un nom exact l'emporte sur
+// une correspondance partielle ; plusieurs correspondances partielles sont une erreur.
+// Les erreurs listent les candidats pour que l'utilisateur puisse corriger sa configuration.
+func findDevice(spec, kind string, def func() (*portaudio.DeviceInfo, error), usable func(*portaudio.DeviceInfo) bool) (*portaudio.DeviceInfo, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		d, err := def()
+		if err != nil {
+			return nil, fmt.Errorf("aucun périphérique %s par défaut: %w", kind, err)
+		}
+		return d, nil
+	}
+
+	devices, err := ListDevices()
+	if err != nil {
+		return nil, err
+	}
+	var candidates []*portaudio.DeviceInfo
+	for _, d := range devices {
+		if usable(d) {
+			candidates = append(candidates, d)
+		}
+	}
+
+	if index, err := strconv.Atoi(spec); err == nil {
+		for _, d := range candidates {
+			if d.Index == index {
+				return d, nil
+			}
+		}
+		return nil, fmt.Errorf("aucun périphérique %s d'index %d. Candidats :\n%s", kind, index, describeDevices(candidates))
+	}
+
+	needle := strings.ToLower(spec)
+	var matches []*portaudio.DeviceInfo
+	for _, d := range candidates {
+		name := strings.ToLower(d.Name)
+		if name == needle {
+			return d, nil
+		}
+		if strings.Contains(name, needle) {
+			matches = append(matches, d)
+		}
+	}
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		return nil, fmt.Errorf("aucun périphérique %s ne correspond à %q. Candidats :\n%s", kind, spec, describeDevices(candidates))
+	default:
+		return nil, fmt.Errorf("%q désigne plusieurs périphériques %s, précisez le nom ou utilisez l'index :\n%s", spec, kind, describeDevices(matches))
+	}
+}
+
+// describeDevices formate une liste courte de périphériques pour les messages d'erreur.
+func describeDevices(devices []*portaudio.DeviceInfo) string {
+	if len(devices) == 0 {
+		return "  (aucun)"
+	}
+	var b strings.Builder
+	for i, d := range devices {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "  [%d] %s (%s)", d.Index, d.Name, hostAPIName(d))
+	}
+	return b.String()
+}
+
+func hostAPIName(d *portaudio.DeviceInfo) string {
+	if d.HostApi == nil {
+		return "?"
+	}
+	return d.HostApi.Name
+}
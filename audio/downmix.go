@@ -0,0 +1,93 @@
+package audio
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DownmixMode choisit comment les canaux d'un micro multi-canal sont ramenés au mono attendu par le VAD.
+type DownmixMode string
+
+const (
+	DownmixAverage DownmixMode = "average" // Moyenne des canaux
+	DownmixSelect  DownmixMode = "select"  // Un canal donné (Downmix.Channel)
+	DownmixLoudest DownmixMode = "loudest" // Le canal le plus énergique, réévalué à chaque frame
+)
+
+// ParseDownmixMode valide un mode lu dans la configuration. Vide : moyenne.
+func ParseDownmixMode(name string) (DownmixMode, error) {
+	switch m := DownmixMode(strings.ToLower(strings.TrimSpace(name))); m {
+	case "":
+		return DownmixAverage, nil
+	case DownmixAverage, DownmixSelect, DownmixLoudest:
+		return m, nil
+	default:
+		return "", fmt.Errorf("mode de downmix inconnu %q (average, select ou loudest)", name)
+	}
+}
+
+// loudestSwitchRatio est l'avance d'énergie (environ 2 dB) qu'un canal doit prendre sur le canal
+// courant pour que DownmixLoudest bascule, afin d'éviter d'alterner entre deux micros proches.
+const loudestSwitchRatio = 1.6
+
+// Downmix convertit des frames entrelacées multi-canal en mono.
+// Il garde le canal choisi par DownmixLoudest d'une frame à l'autre : une instance par flux.
+type Downmix struct {
+	Mode    DownmixMode
+	Channel int // Canal gardé en mode DownmixSelect (à partir de 0)
+
+	current int // Canal retenu à la frame précédente (DownmixLoudest)
+}
+
+// Apply écrit dans out (len(in)/channels échantillons) le mono de la frame entrelacée in.
+func (d *Downmix) Apply(in []int16, channels int, out []int16) {
+	if channels <= 1 {
+		copy(out, in)
+		return
+	}
+	frames := len(in) / channels
+
+	switch d.Mode {
+	case DownmixSelect:
+		for i := range frames {
+			out[i] = in[i*channels+d.Channel]
+		}
+	case DownmixLoudest:
+		ch := d.loudest(in, channels, frames)
+		for i := range frames {
+			out[i] = in[i*channels+ch]
+		}
+	default:
+		for i := range frames {
+			var sum int32
+			for c := range channels {
+				sum += int32(in[i*channels+c])
+			}
+			out[i] = int16(sum / int32(channels))
+		}
+	}
+}
+
+// loudest retourne le canal à garder pour cette frame, avec une hystérésis sur le canal courant.
+func (d *Downmix) loudest(in []int16, channels, frames int) int {
+	if d.current >= channels {
+		d.current = 0
+	}
+	energy := make([]float64, channels)
+	for i := range frames {
+		for c := range channels {
+			s := float64(in[i*channels+c])
+			energy[c] += s * s
+		}
+	}
+	best := d.current
+	for c, e := range energy {
+		if e > energy[best] {
+			best = c
+		}
+	}
+	if best != d.current && energy[best] > energy[d.current]*loudestSwitchRatio {
+		d.current = best
+	}
+	return d.current
+}
@@ -0,0 +1,69 @@
+package audio
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDownmixApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		downmix  Downmix
+		channels int
+		in       []int16
+		want     []int16
+	}{
+		{"mono", Downmix{}, 1, []int16{1, -2, 3}, []int16{1, -2, 3}},
+		{"moyenne", Downmix{Mode: DownmixAverage}, 2, []int16{100, 200, -7, 0}, []int16{150, -3}},
+		{"moyenne aux extrêmes", Downmix{Mode: DownmixAverage}, 2,
+			[]int16{-32768, -32768, 32767, 32767, 32767, -32768}, []int16{-32768, 32767, 0}},
+		{"moyenne 4 canaux saturés", Downmix{Mode: DownmixAverage}, 4,
+			[]int16{32767, 32767, 32767, 32767, -32768, -32768, -32768, -32768}, []int16{32767, -32768}},
+		{"mode vide : moyenne", Downmix{}, 2, []int16{10, 30}, []int16{20}},
+		{"canal 1", Downmix{Mode: DownmixSelect, Channel: 1}, 2, []int16{1, 2, 3, 4}, []int16{2, 4}},
+		{"canal 2 sur 3", Downmix{Mode: DownmixSelect, Channel: 2}, 3, []int16{1, 2, 3, 4, 5, 6}, []int16{3, 6}},
+	}
+	for _, tt := range tests {
+		out := make([]int16, len(tt.in)/tt.channels)
+		tt.downmix.Apply(tt.in, tt.channels, out)
+		if !slices.Equal(out, tt.want) {
+			t.Errorf("%s : %v, attendu %v", tt.name, out, tt.want)
+		}
+	}
+}
+
+// interleave construit une frame de n échantillons où chaque canal a l'amplitude constante donnée.
+func interleave(n int, amplitudes ...int16) []int16 {
+	frame := make([]int16, 0, n*len(amplitudes))
+	for range n {
+		frame = append(frame, amplitudes...)
+	}
+	return frame
+}
+
+func TestDownmixLoudestHysteresis(t *testing.T) {
+	// Le rapport d'énergie est le carré du rapport d'amplitude : 1,2² = 1,44 < loudestSwitchRatio < 1,3² = 1,69.
+	d := Downmix{Mode: DownmixLoudest}
+	steps := []struct {
+		name       string
+		amplitudes []int16
+		want       int16 // Amplitude du canal retenu
+	}{
+		{"égalité : canal 0", []int16{1000, 1000}, 1000},
+		{"canal 1 un peu plus fort : pas de bascule", []int16{1000, 1200}, 1000},
+		{"canal 1 nettement plus fort : bascule", []int16{1000, 1300}, 1300},
+		{"canal 0 un peu plus fort : on reste sur 1", []int16{1200, 1000}, 1000},
+		{"canal 0 nettement plus fort : retour", []int16{2000, 1000}, 2000},
+		{"trois canaux, le 2 domine", []int16{100, 100, 3000}, 3000},
+		{"plus que deux canaux : canal courant remis à 0", []int16{1000, 1200}, 1000},
+	}
+	for _, step := range steps {
+		channels := len(step.amplitudes)
+		in := interleave(160, step.amplitudes...)
+		out := make([]int16, 160)
+		d.Apply(in, channels, out)
+		if out[0] != step.want || out[len(out)-1] != step.want {
+			t.Errorf("%s : canal d'amplitude %d retenu, attendu %d", step.name, out[0], step.want)
+		}
+	}
+}
@@ -5,25 +5,65 @@ import (
 	"io"
 	"log"
 	"sync"
-	"time" // Nécessaire pour les timeouts potentiels
+	"time"
+
+	"tars/audio/convert"
 
 	"github.com/ebitengine/oto/v3"
-	// Pour TTSSampleRate, TTSChannels
 )
 
-// audioChanReader adapte notre channel de PCM en io.Reader pour oto/v3
+// audioChanReader adapte notre channel de PCM en io.Reader pour oto/v3.
+// Il ne bloque jamais : s'il n'y a rien à jouer, il sert du silence. Le player oto
+// reste donc toujours "en lecture" et une nouvelle réponse démarre sans délai,
+// et une interruption n'a qu'à vider les buffers.
+// Les morceaux qui ne sont pas au format du contexte oto sont convertis à la volée.
 type audioChanReader struct {
-	pcmChan chan []byte
-	mu      sync.Mutex
-	buffer  []byte // Buffer interne pour les données non lues
-	closed  bool   // Indique si le channel pcmChan a été fermé
-	reading bool   // Pour éviter les lectures concurrentes sur le player
+	pcmChan   chan convert.Chunk
+	mu        sync.Mutex
+	buffer    []byte         // Buffer interne pour les données non lues (au format du player)
+	closed    bool           // Indique si le channel pcmChan a été fermé
+	format    convert.Format // Format du contexte oto
+	align     int            // Taille d'une frame (échantillon * canaux) en bytes, pour ne jamais couper un échantillon
+	converter *convert.Converter
+	unflushed bool // Le resampler du converter retient la fin du dernier morceau converti
+
+	lastWasAudio bool // Vrai si la dernière lecture a servi du vrai PCM (pas du silence)
 }
 
-func newAudioChanReader(pcmChan chan []byte) *audioChanReader {
+func newAudioChanReader(pcmChan chan convert.Chunk, format convert.Format) *audioChanReader {
 	return &audioChanReader{
 		pcmChan: pcmChan,
+		format:  format,
+		align:   format.BytesPerFrame(),
+	}
+}
+
+// toPlayerFormat convertit un morceau au format du player. Le convertisseur (et l'état de son
+// resampler) est gardé tant que les morceaux arrivent dans le même format.
+func (acr *audioChanReader) toPlayerFormat(chunk convert.Chunk) []byte {
+	if chunk.Format == acr.format || !chunk.Format.Valid() {
+		return chunk.Data
+	}
+	var tail []byte
+	if acr.converter == nil || acr.converter.From() != chunk.Format {
+		tail = acr.flushConverter()
+		log.Printf("TARS AudioPlayer: Conversion %v → %v.", chunk.Format, acr.format)
+		acr.converter = convert.NewConverter(chunk.Format, acr.format)
+	}
+	acr.unflushed = true
+	return append(tail, acr.converter.Convert(chunk.Data)...)
+}
+
+// flushConverter retourne la fin du signal retenue par le resampler et le remet à zéro.
+// Appelé dès que le channel est vide (fin d'une réponse, ou TTS en retard sur la lecture) :
+// ces derniers échantillons doivent être joués avant le silence, et la réponse suivante
+// ne doit pas hériter de l'état du resampler.
+func (acr *audioChanReader) flushConverter() []byte {
+	if acr.converter == nil || !acr.unflushed {
+		return nil
 	}
+	acr.unflushed = false
+	return acr.converter.Flush()
 }
 
 // Read implémente io.Reader
@@ -31,13 +71,30 @@ func (acr *audioChanReader) Read(p []byte) (n int, err error) {
 	acr.mu.Lock()
 	defer acr.mu.Unlock()
 
+	if len(acr.buffer) == 0 && !acr.closed {
+		// Lecture non bloquante : on prend ce qui est disponible.
+		select {
+		case chunk, ok := <-acr.pcmChan:
+			if !ok { // Channel fermé
+				acr.closed = true
+				acr.buffer = acr.flushConverter()
+			} else {
+				acr.buffer = append(acr.buffer, acr.toPlayerFormat(chunk)...)
+			}
+		default:
+			acr.buffer = acr.flushConverter()
+		}
+	}
+
 	// Si on a des données en buffer, on les sert d'abord
 	if len(acr.buffer) > 0 {
 		n = copy(p, acr.buffer)
-		acr.buffer = acr.buffer[n:]
-		if len(acr.buffer) == 0 && acr.closed { // Si buffer vide et channel fermé, c'est la fin
-			return n, io.EOF
+		n -= n % acr.align
+		if n == 0 { // p plus petit qu'une frame : ne devrait pas arriver avec oto
+			n = copy(p, acr.buffer)
 		}
+		acr.buffer = acr.buffer[n:]
+		acr.lastWasAudio = true
 		return n, nil
 	}
 
@@ -46,35 +103,52 @@ func (acr *audioChanReader) Read(p []byte) (n int, err error) {
 		return 0, io.EOF
 	}
 
-	// Lire depuis le channel
-	// On utilise un select pour ne pas bloquer indéfiniment si le chan est vide
-	// et permettre une interruption propre plus tard si nécessaire.
-	select {
-	case data, ok := <-acr.pcmChan:
-		if !ok { // Channel fermé
-			acr.closed = true
-			// log.Println("TARS AudioPlayer (chanReader): pcmChan fermé.")
-			return 0, io.EOF
-		}
-		if len(data) == 0 && acr.closed { // Message vide alors qu'on est en train de fermer
-			return 0, io.EOF
-		}
-		if len(data) == 0 { // Juste un message vide, on attend le suivant
-			return 0, nil // Pas d'erreur, juste 0 bytes lus
-		}
+	// Rien à jouer : silence.
+	n = len(p) - len(p)%acr.align
+	clear(p[:n])
+	acr.lastWasAudio = false
+	return n, nil
+}
+
+// Seek implémente io.Seeker pour que oto accepte player.Seek, utilisé uniquement
+// pour vider le buffer interne du player oto lors d'une interruption.
+func (acr *audioChanReader) Seek(offset int64, whence int) (int64, error) {
+	return 0, nil
+}
 
-		// log.Printf("TARS AudioPlayer (chanReader): Reçu %d bytes du channel", len(data))
-		n = copy(p, data)
-		if n < len(data) { // S'il reste des données non copiées, on les bufferise
-			acr.buffer = append(acr.buffer, data[n:]...)
+// drain vide le buffer et le channel, et retourne la durée d'audio jetée.
+func (acr *audioChanReader) drain() time.Duration {
+	acr.mu.Lock()
+	defer acr.mu.Unlock()
+
+	dropped := acr.format.Duration(len(acr.buffer))
+	acr.buffer = nil
+	acr.lastWasAudio = false
+	if acr.converter != nil {
+		acr.converter.Reset()
+		acr.unflushed = false
+	}
+	for {
+		select {
+		case chunk, ok := <-acr.pcmChan:
+			if !ok {
+				acr.closed = true
+				return dropped
+			}
+			dropped += chunk.Duration()
+		default:
+			return dropped
 		}
-		return n, nil
-	case <-time.After(100 * time.Millisecond): // Timeout pour ne pas bloquer indéfiniment
-		// log.Println("TARS AudioPlayer (chanReader): Timeout lecture channel")
-		return 0, nil // Pas d'erreur, mais 0 bytes lus, Read sera rappelé
 	}
 }
 
+// pending indique s'il reste du PCM à jouer (buffer, channel, ou dernière lecture réelle).
+func (acr *audioChanReader) pending() (queued bool, lastWasAudio bool) {
+	acr.mu.Lock()
+	defer acr.mu.Unlock()
+	return len(acr.buffer) > 0 || len(acr.pcmChan) > 0, acr.lastWasAudio
+}
+
 // Close signale que plus aucune donnée ne viendra sur pcmChan.
 // Ceci est important pour que Read retourne io.EOF après avoir vidé le buffer.
 // Note: ce n'est pas io.Closer, c'est une méthode custom pour notre reader.
@@ -82,33 +156,68 @@ func (acr *audioChanReader) SignalClose() {
 	acr.mu.Lock()
 	defer acr.mu.Unlock()
 	acr.closed = true
-	// log.Println("TARS AudioPlayer (chanReader): SignalClose appelé.")
+}
+
+// output est la partie de *oto.Player utilisée par AudioPlayer, aussi implémentée par
+// portAudioOutput pour jouer sur un périphérique choisi.
+type output interface {
+	Play()
+	Pause()
+	BufferedSize() int
+	Seek(offset int64, whence int) (int64, error)
+	Close() error
 }
 
 type AudioPlayer struct {
-	otoCtx       *oto.Context
-	player       oto.Player // L'interface Player de oto/v3
+	otoCtx       *oto.Context // nil quand la sortie passe par PortAudio
+	player       output       // *oto.Player, ou portAudioOutput si un périphérique est choisi
 	chanReader   *audioChanReader
-	audioPCMChan chan []byte // Le channel d'origine pour recevoir le PCM
+	audioPCMChan chan convert.Chunk // Le channel d'origine pour recevoir le PCM
+	format       convert.Format
 	playingLock  sync.Mutex
 	isPlaying    bool
-	playerWg     sync.WaitGroup // Pour attendre que le player.Play() se termine proprement
 }
 
 // NewAudioPlayer initialise le contexte oto et le player.
-// sampleRate, channels, bitDepth sont pour l'audio à jouer (ex: sortie TTS)
+// sampleRate, channels sont ceux du contexte oto (PCM 16-bit little-endian) ; les morceaux reçus
+// dans un autre format (autre backend TTS, stéréo, float32...) sont convertis à la volée.
+// bufferMs borne la latence de sortie et donc le délai d'une interruption (config.PlayerBufferMs).
+// device choisit le périphérique de sortie (config.OutputDevice) : vide pour la sortie système
+// par défaut via oto, sinon un index ou une partie du nom, joué via PortAudio (voir FindOutputDevice).
 func NewAudioPlayer(
-	audioPCMInChan chan []byte,
+	audioPCMInChan chan convert.Chunk,
 	sampleRate int,
 	channels int,
-	// bitDepth est souvent en bits (ex: 16), oto/v3 utilise un Format.
-	// Pour PCM 16-bit Little Endian (standard):
+	bufferMs int,
+	device string,
 ) (*AudioPlayer, error) {
+	format := convert.Format{SampleRate: sampleRate, Channels: channels, Sample: convert.Int16}
+	chanReader := newAudioChanReader(audioPCMInChan, format)
+	ap := &AudioPlayer{
+		chanReader:   chanReader,
+		audioPCMChan: audioPCMInChan, // On le garde pour le fermer proprement
+		format:       format,
+	}
+
+	if device != "" {
+		dev, err := FindOutputDevice(device)
+		if err != nil {
+			return nil, fmt.Errorf("TARS AudioPlayer: %w", err)
+		}
+		ap.player, err = newPortAudioOutput(dev, format, bufferMs, chanReader)
+		if err != nil {
+			return nil, fmt.Errorf("TARS AudioPlayer: %w", err)
+		}
+		log.Printf("TARS AudioPlayer: Périphérique de sortie [%d] %s (%s).", dev.Index, dev.Name, hostAPIName(dev))
+		return ap, nil
+	}
+
 	op := &oto.NewContextOptions{}
 	op.SampleRate = sampleRate
 	op.ChannelCount = channels
 	// Le format le plus courant pour le PCM 16-bit est SignedInt16LE
 	op.Format = oto.FormatSignedInt16LE // Correspond à PCM 16-bit little-endian
+	op.BufferSize = time.Duration(bufferMs) * time.Millisecond
 
 	otoCtx, readyChan, err := oto.NewContext(op)
 	if err != nil {
@@ -118,137 +227,101 @@ func NewAudioPlayer(
 	<-readyChan // Attendre que le système audio soit prêt
 	log.Println("TARS AudioPlayer: Système audio Oto prêt.")
 
-	chanReader := newAudioChanReader(audioPCMInChan)
+	frameBytes := format.BytesPerFrame()
 
 	// Le player prend un io.Reader. Notre chanReader l'implémente.
 	// Le player créé ici est prêt à être joué, mais ne démarre pas automatiquement.
 	playerInstance := otoCtx.NewPlayer(chanReader)
+	// Buffer côté player réduit : c'est ce qui est déjà parti vers oto quand on interrompt.
+	playerInstance.SetBufferSize(max(format.Bytes(time.Duration(bufferMs)*time.Millisecond), frameBytes))
 
-	return &AudioPlayer{
-		otoCtx:       otoCtx,
-		player:       *playerInstance,
-		chanReader:   chanReader,
-		audioPCMChan: audioPCMInChan, // On le garde pour le fermer proprement
-	}, nil
+	ap.otoCtx = otoCtx
+	ap.player = playerInstance
+	return ap, nil
 }
 
-// Start met en route la lecture continue depuis le channel sur le player.
-// Cette méthode doit être appelée dans une goroutine si elle ne doit pas bloquer.
-// Ou elle peut être bloquante et main.go gère la goroutine.
-// Pour ce POC, on va la rendre bloquante et main.go lancera dans une goroutine dédiée.
+// StartPlaybackLoop met en route la lecture continue depuis le channel.
+// player.Play() de oto/v3 n'est pas bloquant : la lecture tourne jusqu'à Close.
 func (ap *AudioPlayer) StartPlaybackLoop() {
 	ap.playingLock.Lock()
+	defer ap.playingLock.Unlock()
 	if ap.isPlaying {
-		ap.playingLock.Unlock()
 		log.Println("TARS AudioPlayer: Playback loop déjà démarrée.")
 		return
 	}
 	ap.isPlaying = true
-	ap.playingLock.Unlock()
 
 	log.Println("TARS AudioPlayer: Démarrage de la boucle de lecture du player.")
-	ap.playerWg.Add(1) // Incrémenter avant de démarrer la goroutine de player.Play()
-
-	// player.Play() est bloquant jusqu'à ce que le reader retourne io.EOF ou une erreur.
-	// Il doit donc tourner dans sa propre goroutine si l'on veut pouvoir faire autre chose.
-	// Cependant, le player.Play() s'arrête dès que Read() retourne io.EOF une fois.
-	// Si on veut une lecture continue depuis le channel sans recréer le player,
-	// notre chanReader ne doit JAMAIS retourner EOF tant que l'application tourne,
-	// sauf si le AudioPlayer est explicitement arrêté/fermé.
-
-	// Le comportement attendu est que player.Play() tourne en boucle et lit de chanReader
-	// tant que chanReader a des données. Quand chanReader bloque (attente sur channel),
-	// player.Play() bloque aussi.
-	ap.player.Play() // Cette fonction est bloquante et ne retourne pas d'erreur.
-	// Elle se termine quand le reader (ap.chanReader) renvoie io.EOF.
-	log.Println("TARS AudioPlayer: player.Play() terminé (normalement dû à EOF du reader).")
+	ap.player.Play()
+}
 
-	ap.playingLock.Lock()
-	ap.isPlaying = false
-	ap.playingLock.Unlock()
-	ap.playerWg.Done() // Décrémenter quand player.Play() est terminé
-	log.Println("TARS AudioPlayer: Boucle de lecture du player terminée.")
-}
-
-// SendData est une méthode pratique pour envoyer des données au channel interne,
-// mais la struct est initialisée avec le channel, donc c'est le TTSProcessor
-// qui enverra directement sur ce channel. Cette méthode n'est donc plus nécessaire.
-/*
-func (ap *AudioPlayer) SendData(pcmData []byte) {
-    if len(pcmData) == 0 {
-        return
-    }
-    // log.Printf("TARS AudioPlayer: Envoi de %d bytes au channel du player.", len(pcmData))
-    ap.audioPCMChan <- pcmData
-}
-*/
-
-// Interrupt arrête la sortie audio actuelle en vidant le buffer du reader
-// et en signalant au reader de se préparer à se clore si le channel est vide.
-// Dans oto/v3, interrompre directement player.Play() est plus complexe si on veut le reprendre.
-// Pour l'instant, la "vraie" interruption sera gérée par le flux de données :
-// Si le VAD détecte une nouvelle parole utilisateur, le LLM sera (re)sollicité,
-// et une nouvelle réponse TTS viendra remplacer / suivre l'ancienne.
-// Une interruption plus "brutale" pourrait consister à fermer et recréer le player.
-func (ap *AudioPlayer) Interrupt() {
+// Interrupt coupe immédiatement la sortie audio : le PCM en attente sur le channel et
+// dans le buffer du reader est jeté, et le buffer interne du player oto est vidé.
+// Retourne la durée d'audio jetée sans avoir été entendue (approximation à
+// config.PlayerBufferMs près), pour que l'appelant sache ce qui a réellement été dit.
+// L'appelant doit d'abord arrêter ses producteurs (TTS) pour que rien ne soit renvoyé ensuite.
+func (ap *AudioPlayer) Interrupt() time.Duration {
 	ap.playingLock.Lock()
 	defer ap.playingLock.Unlock()
 
-	log.Println("TARS AudioPlayer: Demande d'interruption.")
-	// Vider le buffer interne du chanReader
-	ap.chanReader.mu.Lock()
-	ap.chanReader.buffer = nil
-	// On ne ferme pas pcmChan ici, car il est partagé et peut être réutilisé.
-	// On ne signale pas non plus chanReader.SignalClose(), sinon il ne lira plus jamais.
-	// L'interruption doit être plus "logique" : ne plus envoyer sur pcmChan, ou envoyer du silence.
-	ap.chanReader.mu.Unlock()
+	_, lastWasAudio := ap.chanReader.pending()
+	dropped := ap.chanReader.drain()
+	if !ap.isPlaying {
+		return dropped
+	}
+
+	// Pause arrête la lecture au prochain cycle du mixer oto.
+	ap.player.Pause()
+	if lastWasAudio {
+		// Ce qui restait dans le buffer oto était du vrai PCM non entendu.
+		dropped += ap.format.Duration(ap.player.BufferedSize())
+	}
+	// Seek sur un player en pause vide son buffer interne sans relancer la lecture.
+	if _, err := ap.player.Seek(0, io.SeekCurrent); err != nil {
+		log.Printf("TARS AudioPlayer: Erreur vidage du buffer oto: %v", err)
+	}
+	ap.player.Play()
+
+	log.Printf("TARS AudioPlayer: Interruption, %v d'audio jeté.", dropped)
+	return dropped
+}
 
-	// Pour une interruption plus agressive, on pourrait utiliser player.Pause() s'il était disponible
-	// ou fermer le player actuel et en recréer un nouveau.
-	// oto/v3 n'a pas de Pause/Resume simple sur le Player si le reader est continu.
-	// La solution la plus simple est de simplement arrêter d'envoyer des données au reader.
-	// La prochaine parole du bot remplacera l'ancienne si elle arrive sur le même channel.
-	log.Println("TARS AudioPlayer: Buffer interne vidé. La lecture s'arrêtera si plus de données ne suivent.")
+// Format retourne le format de sortie du player.
+func (ap *AudioPlayer) Format() convert.Format {
+	return ap.format
 }
 
-// Close libère les ressources oto.
+// Close libère les ressources de sortie (oto ou PortAudio).
 func (ap *AudioPlayer) Close() {
 	log.Println("TARS AudioPlayer: Fermeture...")
 
 	// 1. Signaler au chanReader de se terminer (il retournera EOF après avoir vidé son buffer)
-	//    et fermer le channel d'entrée pour débloquer toute goroutine en attente dessus.
-	ap.chanReader.SignalClose() // Important pour que Read retourne EOF
-	close(ap.audioPCMChan)      // Débloque le Read dans chanReader s'il attendait
-
-	// 2. Attendre que la goroutine de player.Play() se termine.
-	//    player.Play() devrait se terminer car chanReader retournera EOF.
-	log.Println("TARS AudioPlayer: En attente de la fin de player.Play()...")
-	ap.playerWg.Wait() // Attendre que la boucle de lecture (player.Play()) soit vraiment finie.
-
-	// 3. Fermer le player (pas explicitement requis si le context est fermé, mais bonne pratique)
-	if ap.player != (oto.Player{}) {
-		err := ap.player.Close()
-		if err != nil {
-			log.Printf("TARS AudioPlayer: Erreur à la fermeture du player oto: %v", err)
-		} else {
-			log.Println("TARS AudioPlayer: Player oto fermé.")
-		}
-	}
+	//    et fermer le channel d'entrée : plus personne ne doit écrire dessus.
+	ap.chanReader.SignalClose()
+	close(ap.audioPCMChan)
+
+	ap.playingLock.Lock()
+	ap.isPlaying = false
+	ap.playingLock.Unlock()
 
-	// 4. Fermer le contexte Oto
-	if ap.otoCtx != nil {
-		log.Println("TARS AudioPlayer: Le contexte Oto se fermera lorsque les players seront fermés et qu'il ne sera plus référencé.")
+	// 2. Fermer le player
+	if err := ap.player.Close(); err != nil {
+		log.Printf("TARS AudioPlayer: Erreur à la fermeture du player: %v", err)
+	} else {
+		log.Println("TARS AudioPlayer: Player fermé.")
 	}
+
+	// 3. Le contexte Oto se ferme lorsque les players sont fermés et qu'il n'est plus référencé.
 	log.Println("TARS AudioPlayer: Fermeture terminée.")
 }
 
-// IsPlaying retourne l'état actuel du player.
+// IsPlaying indique si du PCM est en cours de lecture ou en attente (le bot parle).
 func (ap *AudioPlayer) IsPlaying() bool {
 	ap.playingLock.Lock()
 	defer ap.playingLock.Unlock()
-	// isPlaying est mis à jour par StartPlaybackLoop et Play().
-	// On peut aussi vérifier si le player oto est actif:
-	// return ap.isPlaying && ap.player != nil && ap.player.IsPlaying() // IsPlaying() sur le player oto v3 n'existe pas directement
-	// On se fie à notre propre flag isPlaying, géré par StartPlaybackLoop
-	return ap.isPlaying
+	if !ap.isPlaying {
+		return false
+	}
+	queued, lastWasAudio := ap.chanReader.pending()
+	return queued || lastWasAudio
 }
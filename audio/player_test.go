@@ -0,0 +1,44 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"tars/audio/convert"
+)
+
+// readReply lit r jusqu'au premier silence et retourne le nombre de bytes de PCM servis.
+func readReply(t *testing.T, r *audioChanReader) int {
+	t.Helper()
+	buf := make([]byte, 4096)
+	total := 0
+	for range 1000 {
+		n, err := r.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !r.lastWasAudio {
+			return total
+		}
+		total += n
+	}
+	t.Fatal("pas de silence après la réponse")
+	return total
+}
+
+func TestChanReaderFlushesEachReply(t *testing.T) {
+	// Réponses à 24 kHz jouées à 48 kHz : la fin retenue par le resampler doit être jouée à la
+	// fin de chaque réponse, et la réponse suivante repartir d'un resampler vierge.
+	out := convert.Format{SampleRate: 48000, Channels: 1, Sample: convert.Int16}
+	in := convert.Format{SampleRate: 24000, Channels: 1, Sample: convert.Int16}
+	ch := make(chan convert.Chunk, 64)
+	r := newAudioChanReader(ch, out)
+
+	for reply := range 3 {
+		sendSpeech(ch, in, 500*time.Millisecond)
+		got := readReply(t, r)
+		if want := out.Bytes(500 * time.Millisecond); got < want-4 || got > want+4 {
+			t.Errorf("réponse %d : %d bytes joués, attendu %d", reply, got, want)
+		}
+	}
+}
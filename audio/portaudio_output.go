@@ -0,0 +1,113 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"tars/audio/convert"
+
+	"github.com/gordonklaus/portaudio"
+)
+
+// portAudioOutput joue un io.Reader sur un périphérique PortAudio choisi. oto ne sait ouvrir
+// que la sortie par défaut du système : le player passe par ici quand config.OutputDevice est défini.
+// Il expose le sous-ensemble de *oto.Player utilisé par AudioPlayer.
+type portAudioOutput struct {
+	stream  *portaudio.Stream
+	src     io.Reader
+	buf     []byte
+	paused  atomic.Bool
+	started bool // Flux démarré (premier Play), seul le callback change ensuite avec paused
+}
+
+// newPortAudioOutput ouvre dev en PCM 16-bit au format donné. La lecture ne démarre qu'avec Play.
+// src ne doit jamais bloquer : il est lu depuis le callback temps réel de PortAudio.
+func newPortAudioOutput(dev *portaudio.DeviceInfo, format convert.Format, bufferMs int, src io.Reader) (*portAudioOutput, error) {
+	if dev.MaxOutputChannels < format.Channels {
+		return nil, fmt.Errorf("le périphérique %q n'a que %d canal(aux) de sortie, %d demandé(s)", dev.Name, dev.MaxOutputChannels, format.Channels)
+	}
+	out := &portAudioOutput{src: src}
+	out.paused.Store(true)
+
+	params := portaudio.LowLatencyParameters(nil, dev)
+	params.Output.Channels = format.Channels
+	params.Output.Latency = time.Duration(bufferMs) * time.Millisecond
+	params.SampleRate = float64(format.SampleRate)
+	params.FramesPerBuffer = portaudio.FramesPerBufferUnspecified
+
+	stream, err := portaudio.OpenStream(params, out.fill)
+	if err != nil {
+		return nil, fmt.Errorf("ouverture sortie PortAudio sur %q (%v): %w", dev.Name, format, err)
+	}
+	out.stream = stream
+	return out, nil
+}
+
+// fill est le callback PortAudio : il sert le PCM du reader, ou du silence en pause.
+func (o *portAudioOutput) fill(out []int16) {
+	if o.paused.Load() {
+		clear(out)
+		return
+	}
+	if cap(o.buf) < 2*len(out) {
+		o.buf = make([]byte, 2*len(out))
+	}
+	buf := o.buf[:2*len(out)]
+
+	n := 0
+	for n < len(buf) {
+		m, err := o.src.Read(buf[n:])
+		n += m
+		if err != nil || m == 0 {
+			break
+		}
+	}
+	clear(buf[n:])
+	for i := range out {
+		out[i] = int16(binary.LittleEndian.Uint16(buf[2*i:]))
+	}
+}
+
+func (o *portAudioOutput) Play() {
+	if !o.paused.Swap(false) {
+		return
+	}
+	if o.started {
+		return
+	}
+	if err := o.stream.Start(); err != nil {
+		log.Printf("TARS AudioPlayer: Erreur démarrage sortie PortAudio: %v", err)
+		return
+	}
+	o.started = true
+}
+
+// Pause sert du silence dès le prochain callback ; le flux reste ouvert.
+func (o *portAudioOutput) Pause() {
+	o.paused.Store(true)
+}
+
+// BufferedSize vaut toujours 0 : le callback lit le reader directement, seul le buffer
+// matériel (borné par bufferMs) reste en vol.
+func (o *portAudioOutput) BufferedSize() int {
+	return 0
+}
+
+// Seek n'a rien à vider, voir BufferedSize.
+func (o *portAudioOutput) Seek(offset int64, whence int) (int64, error) {
+	return 0, nil
+}
+
+func (o *portAudioOutput) Close() error {
+	o.paused.Store(true)
+	var err error
+	if o.started {
+		err = o.stream.Stop()
+	}
+	return errors.Join(err, o.stream.Close())
+}
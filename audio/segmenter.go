@@ -0,0 +1,229 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// Utterance est un segment de parole complet prêt pour le STT.
+type Utterance struct {
+	PCM       []int16   // PCM mono 16-bit au taux de capture, pré-roll inclus
+	Start     time.Time // Début de la première frame (pré-roll compris)
+	End       time.Time // Fin de la dernière frame
+	Truncated bool      // Vrai si coupé par la durée maximale plutôt que par un silence
+}
+
+// Duration retourne la durée de l'énoncé.
+func (u Utterance) Duration() time.Duration {
+	return u.End.Sub(u.Start)
+}
+
+type segmenterState int
+
+const (
+	stateSilence segmenterState = iota
+	stateSpeech
+)
+
+// speechDetector est la partie du VAD utilisée par le Segmenter (remplacée par un script dans les tests).
+type speechDetector interface {
+	Process(frame []int16) (bool, error)
+}
+
+// Segmenter découpe le flux de frames de l'AudioCapturer en énoncés, avec hystérésis :
+// la parole commence après speechFrames frames vocales consécutives et se termine après
+// silenceFrames frames de silence consécutives.
+type Segmenter struct {
+	vad           speechDetector
+	inputChan     <-chan []int16
+	outputChan    chan<- Utterance
+	speechStart   chan<- time.Time // optionnel, voir NotifySpeechStart
+	frameDuration time.Duration
+	speechFrames  int // config.VADSpeechFrames
+	silenceFrames int // config.VADSilenceFrames
+	preRollFrames int // frames conservées avant le déclenchement
+	maxFrames     int // longueur maximale d'un énoncé, en frames
+
+	// État de la machine
+	state       segmenterState
+	preRoll     [][]int16 // dernières frames reçues pendant le silence
+	speechRun   int       // frames vocales consécutives (état silence)
+	silenceRun  int       // frames de silence consécutives (état parole)
+	current     []int16
+	frameCount  int // nombre de frames de l'énoncé courant
+	startFrame  int // index (global) de la première frame de l'énoncé courant
+	frameIndex  int // index (global) de la prochaine frame reçue
+	streamStart time.Time
+}
+
+// NewSegmenter crée un segmenteur. preRollMs et maxUtteranceMs sont convertis en frames
+// de frameDurationMs (config.VADPreRollMs, config.VADMaxUtteranceMs).
+func NewSegmenter(
+	vad *VAD,
+	frameDurationMs, speechFrames, silenceFrames, preRollMs, maxUtteranceMs int,
+	inputChan <-chan []int16,
+	outputChan chan<- Utterance,
+) (*Segmenter, error) {
+	if vad == nil {
+		return nil, errors.New("TARS Segmenter: VAD requis")
+	}
+	if frameDurationMs <= 0 || speechFrames <= 0 || silenceFrames <= 0 {
+		return nil, errors.New("TARS Segmenter: durée de frame, VADSpeechFrames et VADSilenceFrames doivent être > 0")
+	}
+
+	preRollFrames := preRollMs / frameDurationMs
+	// Le pré-roll doit au moins contenir les frames qui ont déclenché la parole.
+	if preRollFrames < speechFrames {
+		preRollFrames = speechFrames
+	}
+	maxFrames := maxUtteranceMs / frameDurationMs
+	if maxFrames <= preRollFrames {
+		return nil, errors.New("TARS Segmenter: VADMaxUtteranceMs doit être plus long que le pré-roll")
+	}
+
+	return &Segmenter{
+		vad:           vad,
+		inputChan:     inputChan,
+		outputChan:    outputChan,
+		frameDuration: time.Duration(frameDurationMs) * time.Millisecond,
+		speechFrames:  speechFrames,
+		silenceFrames: silenceFrames,
+		preRollFrames: preRollFrames,
+		maxFrames:     maxFrames,
+	}, nil
+}
+
+// NotifySpeechStart demande au segmenteur de signaler chaque début de parole sur ch
+// (horodatage du début de l'énoncé), par exemple pour interrompre le bot. L'envoi n'est
+// pas bloquant : un événement est perdu si ch est plein. À appeler avant Start.
+func (s *Segmenter) NotifySpeechStart(ch chan<- time.Time) {
+	s.speechStart = ch
+}
+
+// Start consomme les frames jusqu'à la fermeture du canal d'entrée ou l'annulation du contexte,
+// puis ferme le canal de sortie. À lancer dans une goroutine.
+func (s *Segmenter) Start(ctx context.Context) {
+	defer close(s.outputChan)
+	log.Println("TARS Segmenter: Démarré.")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("TARS Segmenter: Contexte annulé, arrêt.")
+			return
+		case frame, ok := <-s.inputChan:
+			if !ok {
+				// Le flux est terminé : on émet l'énoncé en cours s'il y en a un.
+				if s.state == stateSpeech {
+					s.emit(ctx, false)
+				}
+				log.Println("TARS Segmenter: Canal de capture fermé, arrêt.")
+				return
+			}
+			if len(frame) == 0 {
+				continue
+			}
+			s.processFrame(ctx, frame)
+		}
+	}
+}
+
+func (s *Segmenter) processFrame(ctx context.Context, frame []int16) {
+	if s.frameIndex == 0 {
+		s.streamStart = time.Now()
+	}
+	s.frameIndex++
+
+	isSpeech, err := s.vad.Process(frame)
+	if err != nil {
+		log.Printf("TARS Segmenter: Erreur VAD: %v", err)
+		isSpeech = false
+	}
+
+	switch s.state {
+	case stateSilence:
+		s.pushPreRoll(frame)
+		if !isSpeech {
+			s.speechRun = 0
+			return
+		}
+		s.speechRun++
+		if s.speechRun < s.speechFrames {
+			return
+		}
+
+		// Début de parole : le pré-roll (qui contient les frames déclenchantes) ouvre l'énoncé.
+		s.state = stateSpeech
+		s.silenceRun = 0
+		s.current = s.current[:0]
+		for _, f := range s.preRoll {
+			s.current = append(s.current, f...)
+		}
+		s.frameCount = len(s.preRoll)
+		s.startFrame = s.frameIndex - len(s.preRoll)
+		s.preRoll = s.preRoll[:0]
+		log.Println("TARS Segmenter: Début de parole détecté.")
+		if s.speechStart != nil {
+			select {
+			case s.speechStart <- s.streamStart.Add(time.Duration(s.startFrame) * s.frameDuration):
+			default:
+			}
+		}
+
+	case stateSpeech:
+		s.current = append(s.current, frame...)
+		s.frameCount++
+		if isSpeech {
+			s.silenceRun = 0
+		} else {
+			s.silenceRun++
+		}
+
+		switch {
+		case s.silenceRun >= s.silenceFrames:
+			s.emit(ctx, false)
+		case s.frameCount >= s.maxFrames:
+			log.Printf("TARS Segmenter: Durée maximale atteinte (%v), énoncé coupé.", time.Duration(s.maxFrames)*s.frameDuration)
+			s.emit(ctx, true)
+		}
+	}
+}
+
+// pushPreRoll garde les preRollFrames dernières frames. Les frames sont copiées
+// car le producteur peut réutiliser ses buffers.
+func (s *Segmenter) pushPreRoll(frame []int16) {
+	var buf []int16
+	if len(s.preRoll) == s.preRollFrames {
+		buf = s.preRoll[0][:0] // Recycler le buffer le plus ancien
+		copy(s.preRoll, s.preRoll[1:])
+		s.preRoll = s.preRoll[:len(s.preRoll)-1]
+	}
+	buf = append(buf, frame...)
+	s.preRoll = append(s.preRoll, buf)
+}
+
+func (s *Segmenter) emit(ctx context.Context, truncated bool) {
+	pcm := make([]int16, len(s.current))
+	copy(pcm, s.current)
+
+	u := Utterance{
+		PCM:       pcm,
+		Start:     s.streamStart.Add(time.Duration(s.startFrame) * s.frameDuration),
+		End:       s.streamStart.Add(time.Duration(s.startFrame+s.frameCount) * s.frameDuration),
+		Truncated: truncated,
+	}
+
+	s.state = stateSilence
+	s.speechRun = 0
+	s.silenceRun = 0
+	s.frameCount = 0
+	s.current = s.current[:0]
+
+	log.Printf("TARS Segmenter: Fin de parole, énoncé de %v (%d échantillons).", u.Duration(), len(u.PCM))
+	select {
+	case s.outputChan <- u:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,106 @@
+package audio
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// scriptedVAD répond selon un script, une décision par frame.
+type scriptedVAD struct {
+	script []bool
+	next   int
+}
+
+func (v *scriptedVAD) Process([]int16) (bool, error) {
+	speech := v.script[v.next]
+	v.next++
+	return speech, nil
+}
+
+// script construit une suite de décisions : n frames de parole (true) ou de silence (false).
+func script(parts ...any) []bool {
+	var out []bool
+	for i := 0; i < len(parts); i += 2 {
+		for range parts[i].(int) {
+			out = append(out, parts[i+1].(bool))
+		}
+	}
+	return out
+}
+
+// runScript fait passer une frame par décision dans un segmenteur de frames de 20 ms
+// (3 frames pour démarrer, 5 pour finir, 200 ms de pré-roll, 1 s au plus). Chaque frame
+// porte son index dans ses échantillons, pour savoir lesquelles finissent dans les énoncés.
+func runScript(t *testing.T, decisions []bool) []Utterance {
+	t.Helper()
+	vad, err := NewVAD(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := make(chan []int16, len(decisions))
+	utterances := make(chan Utterance, len(decisions))
+	s, err := NewSegmenter(vad, 20, 3, 5, 200, 1000, frames, utterances)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.vad = &scriptedVAD{script: decisions}
+
+	for i := range decisions {
+		frames <- []int16{int16(i), int16(i), int16(i), int16(i)}
+	}
+	close(frames)
+	s.Start(context.Background())
+
+	var out []Utterance
+	for u := range utterances {
+		out = append(out, u)
+	}
+	return out
+}
+
+// checkUtterance vérifie qu'u contient exactement les frames first à first+count-1.
+func checkUtterance(t *testing.T, u Utterance, first, count int, truncated bool) {
+	t.Helper()
+	if len(u.PCM) != 4*count || int(u.PCM[0]) != first || int(u.PCM[len(u.PCM)-1]) != first+count-1 {
+		t.Errorf("énoncé de %d échantillons, frames %d à %d, attendu frames %d à %d",
+			len(u.PCM), u.PCM[0], u.PCM[len(u.PCM)-1], first, first+count-1)
+	}
+	if u.Duration() != time.Duration(count)*20*time.Millisecond {
+		t.Errorf("durée %v, attendu %v", u.Duration(), time.Duration(count)*20*time.Millisecond)
+	}
+	if u.Truncated != truncated {
+		t.Errorf("Truncated = %v, attendu %v", u.Truncated, truncated)
+	}
+}
+
+func TestSegmenterPreRoll(t *testing.T) {
+	// 20 frames de silence, parole des frames 20 à 32, silence ensuite.
+	utterances := runScript(t, script(20, false, 13, true, 5, false, 10, false))
+	if len(utterances) != 1 {
+		t.Fatalf("%d énoncés, attendu 1", len(utterances))
+	}
+	// Pré-roll de 10 frames (13 à 22, déclencheurs compris), puis jusqu'à la 5e frame de silence (37).
+	checkUtterance(t, utterances[0], 13, 25, false)
+}
+
+func TestSegmenterHysteresis(t *testing.T) {
+	// Faux départ (2 frames de parole), puis un énoncé avec un creux de 4 frames de silence.
+	utterances := runScript(t, script(2, true, 1, false, 5, true, 4, false, 5, true, 5, false))
+	if len(utterances) != 1 {
+		t.Fatalf("%d énoncés, attendu 1 : le creux ne doit pas couper l'énoncé", len(utterances))
+	}
+	// Le pré-roll remonte jusqu'au faux départ (10 frames au plus, il n'y en a que 6).
+	checkUtterance(t, utterances[0], 0, 22, false)
+}
+
+func TestSegmenterMaxLength(t *testing.T) {
+	// 123 frames de parole continue : coupées à 50 frames (1 s), reprises sans perte.
+	utterances := runScript(t, script(123, true))
+	if len(utterances) != 3 {
+		t.Fatalf("%d énoncés, attendu 3", len(utterances))
+	}
+	checkUtterance(t, utterances[0], 0, 50, true)
+	checkUtterance(t, utterances[1], 50, 50, true)
+	checkUtterance(t, utterances[2], 100, 23, false) // Émis à la fermeture du flux
+}
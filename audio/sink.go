@@ -0,0 +1,173 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tars/audio/convert"
+	"tars/audio/wav"
+)
+
+// AudioSink joue le PCM reçu sur le canal passé à son constructeur (voir NewAudioPlayer).
+// Toutes les implémentations sont des *AudioPlayer : seule la sortie change (oto, PortAudio,
+// fichier WAV ou rien), si bien que le rythme de lecture, Interrupt et IsPlaying se comportent
+// de la même façon, et que l'orchestrateur tourne à l'identique sans carte son.
+type AudioSink interface {
+	StartPlaybackLoop()
+	Interrupt() time.Duration
+	IsPlaying() bool
+	Format() convert.Format
+	Close()
+}
+
+var _ AudioSink = (*AudioPlayer)(nil)
+
+// clockPeriod est la période à laquelle une sortie sans carte son consomme le PCM.
+const clockPeriod = 10 * time.Millisecond
+
+// NewWAVFileSink crée un player qui écrit ce que dit le bot dans un fichier WAV (PCM 16 bits)
+// au lieu de le jouer. Le PCM est consommé au rythme réel, comme par une carte son ; les
+// silences entre les réponses ne sont pas écrits. Le fichier est finalisé par Close.
+func NewWAVFileSink(audioPCMInChan chan convert.Chunk, path string, sampleRate, channels int) (*AudioPlayer, error) {
+	format := convert.Format{SampleRate: sampleRate, Channels: channels, Sample: convert.Int16}
+	w, err := wav.Create(path, wav.FormatOf(format))
+	if err != nil {
+		return nil, fmt.Errorf("TARS AudioPlayer: %w", err)
+	}
+	log.Printf("TARS AudioPlayer: Sortie vers le fichier %s (%v).", path, format)
+	return newClockedPlayer(audioPCMInChan, format, w), nil
+}
+
+// NewNullSink crée un player qui jette le PCM, consommé au rythme réel : les durées de parole,
+// les interruptions et la fin de lecture restent réalistes (tests, machines sans carte son).
+func NewNullSink(audioPCMInChan chan convert.Chunk, sampleRate, channels int) *AudioPlayer {
+	format := convert.Format{SampleRate: sampleRate, Channels: channels, Sample: convert.Int16}
+	log.Printf("TARS AudioPlayer: Sortie désactivée (%v, rythme réel).", format)
+	return newClockedPlayer(audioPCMInChan, format, nopWriteCloser{io.Discard})
+}
+
+func newClockedPlayer(audioPCMInChan chan convert.Chunk, format convert.Format, w io.WriteCloser) *AudioPlayer {
+	chanReader := newAudioChanReader(audioPCMInChan, format)
+	return &AudioPlayer{
+		player:       newClockOutput(chanReader, format, w),
+		chanReader:   chanReader,
+		audioPCMChan: audioPCMInChan,
+		format:       format,
+	}
+}
+
+// clockOutput remplace la carte son : une horloge lit le reader au rythme réel et écrit
+// le PCM (hors silence) dans w. Elle expose la même interface output que *oto.Player.
+type clockOutput struct {
+	src    *audioChanReader
+	format convert.Format
+	w      io.WriteCloser
+
+	paused atomic.Bool
+	start  sync.Once
+	stop   chan struct{}
+	done   chan struct{}
+	errMu  sync.Mutex
+	err    error // Première erreur d'écriture, retournée par Close
+}
+
+func newClockOutput(src *audioChanReader, format convert.Format, w io.WriteCloser) *clockOutput {
+	o := &clockOutput{src: src, format: format, w: w, stop: make(chan struct{}), done: make(chan struct{})}
+	o.paused.Store(true)
+	return o
+}
+
+func (o *clockOutput) Play() {
+	o.paused.Store(false)
+	o.start.Do(func() { go o.run() })
+}
+
+// run consomme, à chaque tick, la durée d'audio écoulée depuis le tick précédent.
+func (o *clockOutput) run() {
+	defer close(o.done)
+	ticker := time.NewTicker(clockPeriod)
+	defer ticker.Stop()
+
+	buf := make([]byte, o.format.Bytes(4*clockPeriod))
+	last := time.Now()
+	var owed time.Duration
+	for {
+		select {
+		case <-o.stop:
+			return
+		case now := <-ticker.C:
+			owed += now.Sub(last)
+			last = now
+			if o.paused.Load() {
+				owed = 0
+				continue
+			}
+			// Pas de rattrapage au-delà de quelques ticks (machine suspendue, GC...).
+			owed = min(owed, 4*clockPeriod)
+			n := o.format.Bytes(owed)
+			owed -= o.format.Duration(n)
+			if err := o.consume(buf[:n]); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// consume lit len(p) bytes du reader et écrit ceux qui étaient du vrai PCM.
+func (o *clockOutput) consume(p []byte) error {
+	for len(p) > 0 {
+		n, err := o.src.Read(p)
+		if n == 0 || err != nil {
+			return nil // Reader fermé : plus rien à jouer
+		}
+		if _, wasAudio := o.src.pending(); wasAudio {
+			if _, err := o.w.Write(p[:n]); err != nil {
+				o.errMu.Lock()
+				o.err = err
+				o.errMu.Unlock()
+				log.Printf("TARS AudioPlayer: Erreur écriture sortie: %v", err)
+				return err
+			}
+		}
+		p = p[n:]
+	}
+	return nil
+}
+
+func (o *clockOutput) Pause() {
+	o.paused.Store(true)
+}
+
+// BufferedSize vaut 0 : rien n'est lu d'avance, le PCM est consommé au fil de l'horloge.
+func (o *clockOutput) BufferedSize() int {
+	return 0
+}
+
+// Seek n'a rien à vider, voir BufferedSize.
+func (o *clockOutput) Seek(offset int64, whence int) (int64, error) {
+	return 0, nil
+}
+
+// Close arrête l'horloge et ferme la sortie (finalise l'en-tête du WAV).
+func (o *clockOutput) Close() error {
+	o.paused.Store(true)
+	started := true
+	o.start.Do(func() { started = false })
+	if started {
+		close(o.stop)
+		<-o.done
+	}
+	o.errMu.Lock()
+	err := o.err
+	o.errMu.Unlock()
+	return errors.Join(err, o.w.Close())
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
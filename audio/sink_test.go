@@ -0,0 +1,136 @@
+package audio
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tars/audio/convert"
+	"tars/audio/wav"
+)
+
+// sendSpeech envoie d de PCM non nul (format) en morceaux de 20 ms, comme le TTS.
+func sendSpeech(ch chan<- convert.Chunk, format convert.Format, d time.Duration) {
+	chunk := format.Bytes(20 * time.Millisecond)
+	data := make([]byte, format.Bytes(d))
+	for i := range data {
+		data[i] = 0x11
+	}
+	for len(data) > 0 {
+		n := min(chunk, len(data))
+		ch <- convert.Chunk{Data: data[:n], Format: format}
+		data = data[n:]
+	}
+}
+
+// waitIdle attend que le player ait fini de jouer et retourne le temps écoulé depuis start.
+func waitIdle(t *testing.T, sink AudioSink, start time.Time) time.Duration {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for sink.IsPlaying() {
+		if time.Now().After(deadline) {
+			t.Fatal("le player joue toujours")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return time.Since(start)
+}
+
+// readSink relit le fichier écrit par un WAV sink et retourne la durée d'audio enregistrée.
+func readSink(t *testing.T, path string) time.Duration {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := wav.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(len(data)) != r.DataSize() {
+		t.Errorf("en-tête de %d bytes pour %d bytes de données", r.DataSize(), len(data))
+	}
+	return r.Duration()
+}
+
+func TestWAVFileSinkPacing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.wav")
+	ch := make(chan convert.Chunk, 256)
+	sink, err := NewWAVFileSink(ch, path, 24000, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.StartPlaybackLoop()
+
+	// Morceaux au format du TTS OpenAI, puis dans un autre format converti à la volée.
+	start := time.Now()
+	sendSpeech(ch, sink.Format(), 500*time.Millisecond)
+	sendSpeech(ch, convert.Format{SampleRate: 48000, Channels: 2, Sample: convert.Float32}, 500*time.Millisecond)
+	if !sink.IsPlaying() {
+		t.Error("IsPlaying faux avec du PCM en attente")
+	}
+	elapsed := waitIdle(t, sink, start)
+	sink.Close()
+
+	// Consommé au rythme réel, comme par une carte son.
+	if elapsed < 900*time.Millisecond || elapsed > 1500*time.Millisecond {
+		t.Errorf("1 s d'audio joué en %v", elapsed)
+	}
+	if d := readSink(t, path); d < 990*time.Millisecond || d > 1010*time.Millisecond {
+		t.Errorf("%v d'audio dans le fichier, attendu 1s", d)
+	}
+}
+
+func TestWAVFileSinkInterrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.wav")
+	ch := make(chan convert.Chunk, 256)
+	sink, err := NewWAVFileSink(ch, path, 24000, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.StartPlaybackLoop()
+
+	start := time.Now()
+	sendSpeech(ch, sink.Format(), 2*time.Second)
+	time.Sleep(300 * time.Millisecond)
+	dropped := sink.Interrupt()
+	played := time.Since(start)
+	if want := 2*time.Second - played; dropped < want-100*time.Millisecond || dropped > want+100*time.Millisecond {
+		t.Errorf("Interrupt après %v : %v jeté, attendu environ %v", played, dropped, want)
+	}
+	if elapsed := waitIdle(t, sink, time.Now()); elapsed > 50*time.Millisecond {
+		t.Errorf("le player joue encore %v après Interrupt", elapsed)
+	}
+
+	// Le player reste utilisable après une interruption.
+	sendSpeech(ch, sink.Format(), 200*time.Millisecond)
+	waitIdle(t, sink, time.Now())
+	sink.Close()
+
+	if d, want := readSink(t, path), played+200*time.Millisecond; d < want-100*time.Millisecond || d > want+100*time.Millisecond {
+		t.Errorf("%v d'audio dans le fichier, attendu environ %v", d, want)
+	}
+}
+
+func TestNullSinkPacing(t *testing.T) {
+	ch := make(chan convert.Chunk, 256)
+	sink := NewNullSink(ch, 16000, 1)
+	defer sink.Close()
+	if sink.IsPlaying() {
+		t.Error("IsPlaying vrai avant la lecture")
+	}
+	sink.StartPlaybackLoop()
+
+	start := time.Now()
+	sendSpeech(ch, sink.Format(), 300*time.Millisecond)
+	if elapsed := waitIdle(t, sink, start); elapsed < 250*time.Millisecond || elapsed > 800*time.Millisecond {
+		t.Errorf("300 ms d'audio joué en %v", elapsed)
+	}
+}
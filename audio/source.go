@@ -0,0 +1,251 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"tars/audio/convert"
+	"tars/audio/wav"
+)
+
+// AudioSource produit les frames mono du pipeline (sampleRate du VAD, frameDurationMs exactement)
+// sur le canal passé à son constructeur, et le ferme quand elle s'arrête.
+// Start bloque jusqu'à l'annulation de ctx ou la fin de la source ; une source finie (fichier,
+// stdin) retourne nil à la fin de son flux, une erreur n'est retournée que si la source a échoué.
+//
+// Implémentations : AudioCapturer (micro PortAudio), WAVFileSource et PCMReaderSource (stdin),
+// ces deux dernières permettant de faire tourner le pipeline sans carte son (CI, fixtures).
+type AudioSource interface {
+	Start(ctx context.Context) error
+}
+
+// framer découpe un flux PCM de format quelconque en frames mono de taille fixe au format du pipeline.
+type framer struct {
+	from      convert.Format
+	converter *convert.Converter
+	size      int     // Échantillons par frame émise
+	partial   []byte  // Fin de frame d'entrée incomplète, en attente des bytes suivants
+	pending   []int16 // Échantillons convertis pas encore émis
+}
+
+func newFramer(from convert.Format, sampleRate, frameDurationMs int) *framer {
+	to := convert.Format{SampleRate: sampleRate, Channels: 1, Sample: convert.Int16}
+	return &framer{
+		from:      from,
+		converter: convert.NewConverter(from, to),
+		size:      sampleRate * frameDurationMs / 1000,
+	}
+}
+
+// push convertit data et retourne les frames devenues complètes.
+func (f *framer) push(data []byte) [][]int16 {
+	data = append(f.partial, data...)
+	whole := len(data) - len(data)%f.from.BytesPerFrame()
+	f.partial = append([]byte(nil), data[whole:]...)
+	f.pending = append(f.pending, convert.BytesToInt16(f.converter.Convert(data[:whole]))...)
+	return f.frames()
+}
+
+// flush retourne le reste du flux, la dernière frame étant complétée par du silence.
+func (f *framer) flush() [][]int16 {
+	f.pending = append(f.pending, convert.BytesToInt16(f.converter.Flush())...)
+	f.partial = nil
+	if rest := len(f.pending) % f.size; rest != 0 {
+		f.pending = append(f.pending, make([]int16, f.size-rest)...)
+	}
+	return f.frames()
+}
+
+func (f *framer) frames() [][]int16 {
+	var out [][]int16
+	for len(f.pending) >= f.size {
+		out = append(out, f.pending[:f.size:f.size])
+		f.pending = f.pending[f.size:]
+	}
+	// Les frames émises gardent leur tableau : on repart sur un nouveau pour la suite.
+	f.pending = append([]int16(nil), f.pending...)
+	return out
+}
+
+// sendFrames envoie des frames en bloquant (une source fichier ne perd rien),
+// au rythme d'une frame par tick si tick n'est pas nil.
+func sendFrames(ctx context.Context, out chan<- []int16, frames [][]int16, tick <-chan time.Time) error {
+	for _, frame := range frames {
+		if tick != nil {
+			select {
+			case <-tick:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		select {
+		case out <- frame:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// streamFrames lit r jusqu'à EOF et en envoie les frames. Retourne nil en fin de flux.
+func streamFrames(ctx context.Context, r io.Reader, f *framer, out chan<- []int16, tick <-chan time.Time) error {
+	buf := make([]byte, 4096)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if err := sendFrames(ctx, out, f.push(buf[:n]), tick); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// WAVFileSource rejoue un fichier WAV comme s'il venait du micro : converti au format du pipeline,
+// au rythme réel ou aussi vite que le pipeline le consomme, éventuellement en boucle.
+type WAVFileSource struct {
+	path            string
+	sampleRate      int
+	frameDurationMs int
+	realtime        bool // Une frame toutes les frameDurationMs, sinon sans attente
+	loop            bool // Reprend au début à la fin du fichier, jusqu'à l'annulation
+	outputChan      chan<- []int16
+}
+
+// NewWAVFileSource vérifie que path est un WAV lisible. Tout format lu par le package wav est
+// accepté (entier 8 à 32 bits, float), mono ou multi-canal, à toute fréquence.
+func NewWAVFileSource(path string, sampleRate, frameDurationMs int, realtime, loop bool, outputChan chan<- []int16) (*WAVFileSource, error) {
+	s := &WAVFileSource{
+		path:            path,
+		sampleRate:      sampleRate,
+		frameDurationMs: frameDurationMs,
+		realtime:        realtime,
+		loop:            loop,
+		outputChan:      outputChan,
+	}
+	file, wr, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	file.Close()
+	log.Printf("TARS WAVFileSource: %s (%v, %v), temps réel: %v, boucle: %v.",
+		path, wr.Format(), wr.Duration().Round(time.Millisecond), realtime, loop)
+	return s, nil
+}
+
+// open ouvre le fichier et lit son en-tête ; les échantillons sont lus au fil de la lecture.
+func (s *WAVFileSource) open() (*os.File, *wav.Reader, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("source WAV: %w", err)
+	}
+	wr, err := wav.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("source WAV %s: %w", s.path, err)
+	}
+	return file, wr, nil
+}
+
+// Start implémente AudioSource.
+func (s *WAVFileSource) Start(ctx context.Context) error {
+	defer close(s.outputChan)
+
+	var tick <-chan time.Time
+	if s.realtime {
+		ticker := time.NewTicker(time.Duration(s.frameDurationMs) * time.Millisecond)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		if err := s.play(ctx, tick); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if !s.loop {
+			log.Printf("TARS WAVFileSource: Fin de %s.", s.path)
+			return nil
+		}
+	}
+}
+
+// play envoie le fichier une fois en entier.
+func (s *WAVFileSource) play(ctx context.Context, tick <-chan time.Time) error {
+	file, wr, err := s.open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	pcm, format := wr.PCM()
+
+	f := newFramer(format, s.sampleRate, s.frameDurationMs)
+	if err := streamFrames(ctx, pcm, f, s.outputChan, tick); err != nil {
+		return fmt.Errorf("source WAV %s: %w", s.path, err)
+	}
+	return sendFrames(ctx, s.outputChan, f.flush(), tick)
+}
+
+// PCMReaderSource lit du PCM brut (par exemple os.Stdin, alimenté par `arecord` ou `ffmpeg`)
+// au format donné. Le rythme est celui de l'écrivain ; la source se termine à EOF.
+type PCMReaderSource struct {
+	r               io.Reader
+	format          convert.Format
+	sampleRate      int
+	frameDurationMs int
+	outputChan      chan<- []int16
+}
+
+// NewPCMReaderSource crée une source sur r, qui produit du PCM entrelacé au format format.
+func NewPCMReaderSource(r io.Reader, format convert.Format, sampleRate, frameDurationMs int, outputChan chan<- []int16) (*PCMReaderSource, error) {
+	if !format.Valid() {
+		return nil, fmt.Errorf("source PCM: format invalide %v", format)
+	}
+	return &PCMReaderSource{
+		r:               r,
+		format:          format,
+		sampleRate:      sampleRate,
+		frameDurationMs: frameDurationMs,
+		outputChan:      outputChan,
+	}, nil
+}
+
+// Start implémente AudioSource. Un Read bloqué (stdin) n'est pas interruptible : à l'annulation,
+// Start rend la main sans attendre la goroutine de lecture, qui s'arrêtera à la donnée suivante.
+func (s *PCMReaderSource) Start(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() { done <- s.stream(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+func (s *PCMReaderSource) stream(ctx context.Context) error {
+	defer close(s.outputChan)
+
+	f := newFramer(s.format, s.sampleRate, s.frameDurationMs)
+	if err := streamFrames(ctx, s.r, f, s.outputChan, nil); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("source PCM: %w", err)
+	}
+	log.Println("TARS PCMReaderSource: Fin du flux.")
+	sendFrames(ctx, s.outputChan, f.flush(), nil) // Seule erreur possible : l'annulation, qui n'en est pas une
+	return nil
+}
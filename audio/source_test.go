@@ -0,0 +1,165 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"tars/audio/convert"
+	"tars/audio/wav"
+)
+
+// segment fait passer les frames de source par le VAD et le segmenteur (réglages par défaut
+// de config) et retourne les énoncés détectés.
+func segment(t *testing.T, source AudioSource, frames chan []int16) []Utterance {
+	t.Helper()
+	vad, err := NewVAD(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utterances := make(chan Utterance, 4)
+	segmenter, err := NewSegmenter(vad, 20, 3, 25, 300, 30000, frames, utterances)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	sourceErr := make(chan error, 1)
+	go func() { sourceErr <- source.Start(ctx) }()
+	go segmenter.Start(ctx)
+
+	var out []Utterance
+	for u := range utterances {
+		out = append(out, u)
+	}
+	if err := <-sourceErr; err != nil {
+		t.Fatal(err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("source ou segmenteur bloqué")
+	}
+	return out
+}
+
+// checkUtterances vérifie les énoncés de testdata/speech_quiet.wav : parole de 1.0 à 2.4 s et
+// de 3.6 à 5.8 s, chacune précédée du pré-roll (300 ms) et suivie du silence de fin (500 ms).
+func checkUtterances(t *testing.T, utterances []Utterance) {
+	t.Helper()
+	if len(utterances) != 2 {
+		t.Fatalf("%d énoncés, attendu 2", len(utterances))
+	}
+	const tolerance = 80 * time.Millisecond
+	near := func(got, want time.Duration) bool { return got > want-tolerance && got < want+tolerance }
+	for i, want := range []time.Duration{2200 * time.Millisecond, 3000 * time.Millisecond} {
+		u := utterances[i]
+		if !near(u.Duration(), want) {
+			t.Errorf("énoncé %d : %v, attendu %v", i, u.Duration(), want)
+		}
+		if pcm := time.Duration(len(u.PCM)) * time.Second / SampleRate; pcm != u.Duration() {
+			t.Errorf("énoncé %d : %v de PCM pour une durée de %v", i, pcm, u.Duration())
+		}
+		if u.Truncated {
+			t.Errorf("énoncé %d coupé", i)
+		}
+	}
+	if gap := utterances[1].Start.Sub(utterances[0].Start); !near(gap, 2600*time.Millisecond) {
+		t.Errorf("%v entre les débuts d'énoncés, attendu 2.6s", gap)
+	}
+}
+
+func TestWAVFileSourceSegmenter(t *testing.T) {
+	frames := make(chan []int16, 50)
+	source, err := NewWAVFileSource(filepath.Join("testdata", "speech_quiet.wav"), SampleRate, 20, false, false, frames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkUtterances(t, segment(t, source, frames))
+}
+
+func TestWAVFileSourceConverts(t *testing.T) {
+	// Le même enregistrement en stéréo float 48 kHz : converti en mono 16 kHz par la source.
+	data, err := os.ReadFile(filepath.Join("testdata", "speech_quiet.wav"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := wav.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pcm, from := r.PCM()
+	mono, err := io.ReadAll(pcm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := convert.Format{SampleRate: 48000, Channels: 2, Sample: convert.Float32}
+	conv := convert.NewConverter(from, to)
+	stereo := append(conv.Convert(mono), conv.Flush()...)
+
+	path := filepath.Join(t.TempDir(), "stereo.wav")
+	w, err := wav.Create(path, wav.FormatOf(to))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(stereo); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	frames := make(chan []int16, 50)
+	source, err := NewWAVFileSource(path, SampleRate, 20, false, false, frames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkUtterances(t, segment(t, source, frames))
+}
+
+func TestPCMReaderSourceSegmenter(t *testing.T) {
+	// PCM brut reçu par petits morceaux, comme sur un pipe (`arecord | tars run -input -`).
+	data, err := os.ReadFile(filepath.Join("testdata", "speech_quiet.wav"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := wav.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := make(chan []int16, 50)
+	format := convert.Format{SampleRate: SampleRate, Channels: 1, Sample: convert.Int16}
+	source, err := NewPCMReaderSource(iotest.HalfReader(r), format, SampleRate, 20, frames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkUtterances(t, segment(t, source, frames))
+}
+
+func TestWAVFileSourceRealtime(t *testing.T) {
+	frames := make(chan []int16, 50)
+	source, err := NewWAVFileSource(filepath.Join("testdata", "speech_quiet.wav"), SampleRate, 20, true, false, frames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go source.Start(ctx)
+
+	start := time.Now()
+	for range 25 { // 500 ms d'audio
+		if frame := <-frames; len(frame) != SampleRate*20/1000 {
+			t.Fatalf("frame de %d échantillons", len(frame))
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("500 ms d'audio reçus en %v, attendu le rythme réel", elapsed)
+	}
+	cancel()
+	for range frames { // La source ferme son canal à l'annulation
+	}
+}
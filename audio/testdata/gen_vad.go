@@ -0,0 +1,130 @@
+//go:build ignore
+
+// Génère les fixtures du VAD : go run gen_vad.go (depuis audio/testdata).
+// Chaque fichier est accompagné d'un fichier de labels au format Audacity
+// (début et fin en secondes, séparés par des tabulations) donnant les zones de parole.
+// speech_jfk.wav n'est pas généré : c'est un vrai enregistrement (voir speech_jfk.wav.license),
+// labellisé phrase par phrase d'après le texte du discours et son enveloppe d'énergie.
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+	"math"
+	"math/rand/v2"
+	"os"
+	"strings"
+
+	"tars/audio/wav"
+)
+
+const sampleRate = 16000
+
+// region est une zone du fichier, en secondes.
+type region struct{ start, end float64 }
+
+// fixture décrit un fichier : niveau du bruit de fond, énoncés et éventuel souffle parasite.
+type fixture struct {
+	name    string
+	noiseDB float64  // Bruit de fond (dBFS RMS)
+	speech  []region // Énoncés, écrits dans le fichier de labels
+	hiss    []region // Souffle aigu (ni parole ni bruit de fond)
+}
+
+var fixtures = []fixture{
+	{name: "speech_quiet", noiseDB: -65, speech: []region{{1.0, 2.4}, {3.6, 5.8}}},
+	{name: "speech_noisy", noiseDB: -45, speech: []region{{1.0, 2.4}, {4.0, 6.2}}, hiss: []region{{2.9, 3.4}}},
+}
+
+func main() {
+	for i, f := range fixtures {
+		if err := generate(f, rand.New(rand.NewPCG(1, uint64(i)))); err != nil {
+			log.Fatalf("%s: %v", f.name, err)
+		}
+	}
+}
+
+func generate(f fixture, rng *rand.Rand) error {
+	const duration = 7.0
+	out := make([]float64, int(duration*sampleRate))
+
+	// Bruit de fond : bruit blanc filtré (un pôle), comme un ventilateur.
+	noiseGain := math.Pow(10, f.noiseDB/20) * 2.3
+	var lp float64
+	for i := range out {
+		lp += 0.3 * (rng.NormFloat64() - lp)
+		out[i] = noiseGain * lp
+	}
+	for _, r := range f.speech {
+		addUtterance(out, r, rng)
+	}
+	for _, r := range f.hiss {
+		addHiss(out, r, rng)
+	}
+
+	w, err := wav.Create(f.name+".wav", wav.Format{SampleRate: sampleRate, Channels: 1, BitsPerSample: 16})
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 2*len(out))
+	for i, x := range out {
+		binary.LittleEndian.PutUint16(buf[2*i:], uint16(int16(math.Round(math.Max(-1, math.Min(1, x))*32767))))
+	}
+	if _, err := w.Write(buf); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	var labels strings.Builder
+	for _, r := range f.speech {
+		fmt.Fprintf(&labels, "%.6f\t%.6f\tparole\n", r.start, r.end)
+	}
+	return os.WriteFile(f.name+".txt", []byte(labels.String()), 0o644)
+}
+
+// addUtterance ajoute un énoncé voisé : syllabes de 150 à 250 ms (harmoniques d'un F0 qui varie,
+// mises en forme par deux formants), séparées par de courtes baisses d'énergie.
+func addUtterance(out []float64, r region, rng *rand.Rand) {
+	t := r.start
+	for t < r.end {
+		syllable := math.Min(0.15+0.1*rng.Float64(), r.end-t)
+		level := math.Pow(10, (-22+6*rng.Float64())/20)
+		f0 := 110 + 50*rng.Float64()
+		f1 := 450 + 300*rng.Float64()
+		f2 := 1100 + 800*rng.Float64()
+		start, n := int(t*sampleRate), int(syllable*sampleRate)
+		for i := 0; i < n && start+i < len(out); i++ {
+			x := float64(i) / sampleRate
+			env := math.Sin(math.Pi * float64(i) / float64(n)) // Attaque et chute de la syllabe
+			env = 0.2 + 0.8*env
+			var s float64
+			for h := 1; float64(h)*f0 < 3800; h++ {
+				fh := float64(h) * f0 * (1 + 0.05*x) // Légère intonation
+				gain := formant(fh, f1, 120) + 0.5*formant(fh, f2, 200) + 0.05
+				s += gain * math.Sin(2*math.Pi*fh*x)
+			}
+			out[start+i] += level * env * s / 3
+		}
+		t += syllable
+	}
+}
+
+func formant(f, center, width float64) float64 {
+	d := (f - center) / width
+	return math.Exp(-d * d / 2)
+}
+
+// addHiss ajoute un souffle aigu (bruit blanc dérivé, énergie au-dessus de la bande vocale).
+func addHiss(out []float64, r region, rng *rand.Rand) {
+	gain := math.Pow(10, -30.0/20)
+	prev := 0.0
+	for i := int(r.start * sampleRate); i < int(r.end*sampleRate) && i < len(out); i++ {
+		x := rng.NormFloat64()
+		out[i] += gain * (x - prev) / 2
+		prev = x
+	}
+}
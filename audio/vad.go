@@ -1,7 +1,13 @@
-// Fichier: audio/vad.go (APRÈS - Option 1: Pas de VAD)
+// Fichier: audio/vad.go
+// VAD en Go pur (sans cgo) : énergie de la frame, taux de passage par zéro (ZCR)
+// et énergie dans la bande vocale, comparés à un plancher de bruit adaptatif.
 package audio
 
-import "fmt" // Plus besoin de go-webrtcvad pour l'instant
+import (
+	"errors"
+	"fmt"
+	"math"
+)
 
 const (
 	SampleRate    = 16000
@@ -10,21 +16,216 @@ const (
 	BitDepth      = 16
 )
 
+// vadThresholds regroupe les seuils d'un niveau d'agressivité.
+type vadThresholds struct {
+	energyMarginDB float64 // dB minimum au-dessus du plancher de bruit
+	minEnergyDB    float64 // énergie absolue minimale (dBFS) pour être de la parole
+	minBandRatio   float64 // part minimale de l'énergie dans la bande vocale (250–3400 Hz)
+	maxZCR         float64 // taux de passage par zéro maximum (souffle, bruit blanc au-delà)
+}
+
+// vadLevels associe config.VADAggressiveness (0 à 3) à des seuils.
+// Plus l'agressivité est haute, plus il faut de preuves pour déclarer de la parole.
+var vadLevels = [4]vadThresholds{
+	{energyMarginDB: 6, minEnergyDB: -55, minBandRatio: 0.15, maxZCR: 0.50},
+	{energyMarginDB: 8, minEnergyDB: -50, minBandRatio: 0.20, maxZCR: 0.42},
+	{energyMarginDB: 10, minEnergyDB: -46, minBandRatio: 0.25, maxZCR: 0.36},
+	{energyMarginDB: 13, minEnergyDB: -42, minBandRatio: 0.30, maxZCR: 0.30},
+}
+
+const (
+	vadWarmupFrames   = 10    // frames utilisées pour initialiser le plancher de bruit
+	vadFloorMinDB     = -90.0 // bornes du plancher de bruit
+	vadFloorMaxDB     = -20.0
+	vadFloorFallRate  = 0.10  // adaptation rapide quand le bruit baisse
+	vadFloorRiseRate  = 0.01  // adaptation lente quand le bruit monte (frames de silence)
+	vadFloorSpeechAdj = 0.001 // dérive très lente pendant la parole (bruit de fond qui monte)
+)
+
+// VADResult détaille la décision pour une frame (utile pour le réglage et les tests sur fixtures).
+type VADResult struct {
+	Speech       bool
+	EnergyDB     float64 // énergie de la frame en dBFS
+	NoiseFloorDB float64 // plancher de bruit au moment de la décision
+	ZCR          float64 // passages par zéro / échantillon
+	BandRatio    float64 // énergie bande vocale / énergie totale
+}
+
 type VAD struct {
-	// Plus besoin de vadInstance
+	sampleRate int
+	thresholds vadThresholds
+
+	// Filtres de bande vocale, l'état est conservé d'une frame à l'autre.
+	highPass biquad
+	lowPass  biquad
+
+	noiseFloorDB float64
+	frames       int
 }
 
-// NewVAD ne fait plus grand chose, mais on garde la signature pour compatibilité
+// NewVAD crée un détecteur pour de l'audio mono 16-bit à SampleRate.
+// aggressiveness va de 0 (le moins agressif) à 3 (le plus agressif).
 func NewVAD(aggressiveness int) (*VAD, error) {
-	fmt.Println("WARN: VAD désactivé pour le test. Tous les chunks audio seront considérés comme vocaux.")
-	return &VAD{}, nil
+	return NewVADWithSampleRate(aggressiveness, SampleRate)
+}
+
+// NewVADWithSampleRate est identique à NewVAD pour un taux d'échantillonnage donné.
+func NewVADWithSampleRate(aggressiveness, sampleRate int) (*VAD, error) {
+	if aggressiveness < 0 || aggressiveness >= len(vadLevels) {
+		return nil, fmt.Errorf("VAD: agressivité %d invalide (attendu 0 à %d)", aggressiveness, len(vadLevels)-1)
+	}
+	if sampleRate < 8000 {
+		return nil, fmt.Errorf("VAD: taux d'échantillonnage %d Hz trop bas (minimum 8000 Hz)", sampleRate)
+	}
+
+	v := &VAD{
+		sampleRate: sampleRate,
+		thresholds: vadLevels[aggressiveness],
+	}
+	v.Reset()
+	return v, nil
 }
 
-// Process retourne toujours true, ou vous pouvez la modifier pour qu'elle ne soit plus appelée
+// Reset oublie le plancher de bruit et l'état des filtres (nouveau flux audio).
+func (v *VAD) Reset() {
+	v.highPass = newHighPass(float64(v.sampleRate), 250)
+	v.lowPass = newLowPass(float64(v.sampleRate), 3400)
+	v.noiseFloorDB = vadFloorMaxDB
+	v.frames = 0
+}
+
+// Process indique si la frame contient de la parole.
 func (v *VAD) Process(pcm []int16) (bool, error) {
-	return true, nil // Simule que la voix est toujours présente
+	res, err := v.Analyze(pcm)
+	if err != nil {
+		return false, err
+	}
+	return res.Speech, nil
+}
+
+// Analyze est comme Process mais retourne aussi les mesures utilisées pour la décision.
+func (v *VAD) Analyze(pcm []int16) (VADResult, error) {
+	if len(pcm) == 0 {
+		return VADResult{}, errors.New("VAD: frame vide")
+	}
+
+	var total, band float64
+	crossings, zeros := 0, 0
+	prev := pcm[0]
+	for _, s := range pcm {
+		if s == 0 {
+			zeros++
+		}
+		x := float64(s) / 32768.0
+		total += x * x
+
+		y := v.lowPass.process(v.highPass.process(x))
+		band += y * y
+
+		if (s >= 0) != (prev >= 0) {
+			crossings++
+		}
+		prev = s
+	}
+
+	n := float64(len(pcm))
+	res := VADResult{
+		EnergyDB: 10 * math.Log10(total/n+1e-12),
+		ZCR:      float64(crossings) / n,
+	}
+	if total > 0 {
+		res.BandRatio = band / total
+	}
+
+	if 2*zeros > len(pcm) {
+		// Silence numérique (flux qui démarre, micro coupé) : ce n'est pas le bruit de fond de la pièce,
+		// il ne doit ni compter dans l'amorçage ni faire tomber le plancher. Même un micro très
+		// silencieux ne rend pas une majorité d'échantillons exactement nuls.
+		res.NoiseFloorDB = v.noiseFloorDB
+		return res, nil
+	}
+
+	v.frames++
+	if v.frames <= vadWarmupFrames {
+		// Pendant l'amorçage on suit le minimum observé, sans déclarer de parole.
+		if v.frames == 1 || res.EnergyDB < v.noiseFloorDB {
+			v.noiseFloorDB = clampFloor(res.EnergyDB)
+		}
+		res.NoiseFloorDB = v.noiseFloorDB
+		return res, nil
+	}
+
+	t := v.thresholds
+	res.NoiseFloorDB = v.noiseFloorDB
+	res.Speech = res.EnergyDB >= v.noiseFloorDB+t.energyMarginDB &&
+		res.EnergyDB >= t.minEnergyDB &&
+		res.BandRatio >= t.minBandRatio &&
+		res.ZCR <= t.maxZCR
+
+	v.updateNoiseFloor(res.EnergyDB, res.Speech)
+	return res, nil
+}
+
+func (v *VAD) updateNoiseFloor(energyDB float64, speech bool) {
+	rate := vadFloorRiseRate
+	switch {
+	case energyDB < v.noiseFloorDB:
+		rate = vadFloorFallRate
+	case speech:
+		rate = vadFloorSpeechAdj
+	}
+	v.noiseFloorDB = clampFloor(v.noiseFloorDB + rate*(energyDB-v.noiseFloorDB))
+}
+
+func clampFloor(db float64) float64 {
+	return math.Max(vadFloorMinDB, math.Min(vadFloorMaxDB, db))
 }
 
 func (v *VAD) Close() {
 	// Rien à faire
 }
+
+const butterworthQ = 1 / math.Sqrt2
+
+// biquad est un filtre du second ordre (RBJ Audio EQ Cookbook), forme directe I.
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func newHighPass(sampleRate, cutoff float64) biquad {
+	w := 2 * math.Pi * cutoff / sampleRate
+	alpha := math.Sin(w) / (2 * butterworthQ)
+	cos := math.Cos(w)
+	a0 := 1 + alpha
+	return biquad{
+		b0: (1 + cos) / 2 / a0,
+		b1: -(1 + cos) / a0,
+		b2: (1 + cos) / 2 / a0,
+		a1: -2 * cos / a0,
+		a2: (1 - alpha) / a0,
+	}
+}
+
+func newLowPass(sampleRate, cutoff float64) biquad {
+	// Au-delà de Nyquist, le passe-bas n'a pas d'effet utile.
+	cutoff = math.Min(cutoff, sampleRate*0.45)
+	w := 2 * math.Pi * cutoff / sampleRate
+	alpha := math.Sin(w) / (2 * butterworthQ)
+	cos := math.Cos(w)
+	a0 := 1 + alpha
+	return biquad{
+		b0: (1 - cos) / 2 / a0,
+		b1: (1 - cos) / a0,
+		b2: (1 - cos) / 2 / a0,
+		a1: -2 * cos / a0,
+		a2: (1 - alpha) / a0,
+	}
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
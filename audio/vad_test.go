@@ -0,0 +1,271 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"tars/audio/wav"
+)
+
+const testFrameSamples = SampleRate * 20 / 1000 // Frames de 20 ms, comme config.VADFrameDurationMs
+
+// loadFixture lit testdata/name.wav (16 kHz mono 16 bits) en frames de 20 ms, et les zones
+// de parole de testdata/name.txt (labels Audacity : début et fin en secondes).
+func loadFixture(t *testing.T, name string) (frames [][]int16, speech []bool) {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name+".wav"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := wav.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Format(); got != (wav.Format{SampleRate: SampleRate, Channels: 1, BitsPerSample: 16}) {
+		t.Fatalf("format %v, attendu 16 kHz mono 16 bits", got)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := make([]int16, len(data)/2)
+	for i := range samples {
+		samples[i] = int16(binary.LittleEndian.Uint16(data[2*i:]))
+	}
+	for len(samples) >= testFrameSamples {
+		frames = append(frames, samples[:testFrameSamples])
+		samples = samples[testFrameSamples:]
+	}
+
+	labels, err := os.Open(filepath.Join("testdata", name+".txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer labels.Close()
+	speech = make([]bool, len(frames))
+	scanner := bufio.NewScanner(labels)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 2 {
+			continue
+		}
+		start, err1 := strconv.ParseFloat(fields[0], 64)
+		end, err2 := strconv.ParseFloat(fields[1], 64)
+		if err1 != nil || err2 != nil {
+			t.Fatalf("label invalide : %q", scanner.Text())
+		}
+		// Une frame est de la parole si son milieu tombe dans la zone.
+		for i := range speech {
+			mid := (float64(i) + 0.5) * 0.020
+			if mid >= start && mid < end {
+				speech[i] = true
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return frames, speech
+}
+
+// vadExpectations donne, par fixture et par agressivité (0 à 3), l'exactitude minimale par frame
+// hors amorçage. Plus le VAD est agressif, plus il laisse passer de parole dans le bruit.
+// speech_jfk est un vrai enregistrement (discours dans le bruit de la foule) labellisé par
+// phrase : les silences entre les mots, que les niveaux agressifs écartent, comptent comme parole.
+var vadExpectations = map[string][4]float64{
+	"speech_quiet": {0.97, 0.97, 0.97, 0.97},
+	"speech_noisy": {0.95, 0.93, 0.85, 0.65},
+	"speech_jfk":   {0.92, 0.89, 0.84, 0.77},
+}
+
+func TestVADFixtures(t *testing.T) {
+	for name, minAccuracy := range vadExpectations {
+		frames, labels := loadFixture(t, name)
+		prevRecall, prevFalseAlarms := 1.0, 1.0
+		for level := range vadLevels {
+			vad, err := NewVAD(level)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var correct, hits, speech, falseAlarms, silence int
+			for i, frame := range frames {
+				got, err := vad.Process(frame)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if i < vadWarmupFrames {
+					continue
+				}
+				if got == labels[i] {
+					correct++
+				}
+				if labels[i] {
+					speech++
+					if got {
+						hits++
+					}
+				} else {
+					silence++
+					if got {
+						falseAlarms++
+					}
+				}
+			}
+			accuracy := float64(correct) / float64(len(frames)-vadWarmupFrames)
+			recall := float64(hits) / float64(speech)
+			falseAlarmRate := float64(falseAlarms) / float64(silence)
+			t.Logf("%s, agressivité %d : exactitude %.3f, rappel %.3f, fausses alarmes %.3f", name, level, accuracy, recall, falseAlarmRate)
+
+			if accuracy < minAccuracy[level] {
+				t.Errorf("%s, agressivité %d : exactitude %.3f < %.3f", name, level, accuracy, minAccuracy[level])
+			}
+			if recall > prevRecall || falseAlarmRate > prevFalseAlarms {
+				t.Errorf("%s, agressivité %d : plus permissif que le niveau précédent", name, level)
+			}
+			prevRecall, prevFalseAlarms = recall, falseAlarmRate
+		}
+	}
+}
+
+// noiseFrame retourne une frame de bruit filtré (ventilateur) de niveau RMS approximatif levelDB.
+func noiseFrame(rng *rand.Rand, levelDB float64) []int16 {
+	gain := math.Pow(10, levelDB/20) * 2.3 * 32767
+	frame := make([]int16, testFrameSamples)
+	var lp float64
+	for i := range frame {
+		lp += 0.3 * (rng.NormFloat64() - lp)
+		frame[i] = int16(gain * lp)
+	}
+	return frame
+}
+
+func TestVADWarmup(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	vad, err := NewVAD(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	minDB := 0.0
+	for i := range vadWarmupFrames {
+		levelDB := -60.0
+		if i%3 == 0 {
+			levelDB = -25 // Parole dès le démarrage : jamais déclarée pendant l'amorçage
+		}
+		res, err := vad.Analyze(noiseFrame(rng, levelDB))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Speech {
+			t.Errorf("frame %d déclarée parole pendant l'amorçage", i)
+		}
+		minDB = math.Min(minDB, res.EnergyDB)
+		if res.NoiseFloorDB != minDB {
+			t.Errorf("frame %d : plancher %.1f dB, attendu le minimum observé %.1f dB", i, res.NoiseFloorDB, minDB)
+		}
+	}
+	if res, _ := vad.Analyze(noiseFrame(rng, -25)); !res.Speech {
+		t.Errorf("parole non détectée après l'amorçage : %+v", res)
+	}
+
+	vad.Reset()
+	if res, _ := vad.Analyze(noiseFrame(rng, -25)); res.Speech {
+		t.Error("Reset ne relance pas l'amorçage")
+	}
+}
+
+func TestVADDigitalSilence(t *testing.T) {
+	// Un flux qui commence par des zéros (carte son qui démarre) : l'amorçage attend le vrai bruit
+	// de fond, sinon tout ce qui suit passerait pour de la parole.
+	rng := rand.New(rand.NewPCG(1, 2))
+	vad, err := NewVAD(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	half := noiseFrame(rng, -45)
+	clear(half[:len(half)*9/10]) // Frame presque entièrement à zéro
+	frames := [][]int16{make([]int16, testFrameSamples), make([]int16, testFrameSamples), half}
+	for range vadWarmupFrames {
+		frames = append(frames, noiseFrame(rng, -45))
+	}
+	var res VADResult
+	for _, frame := range frames {
+		if res, err = vad.Analyze(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if res.NoiseFloorDB < -50 {
+		t.Errorf("plancher %.1f dB après l'amorçage, attendu le bruit de fond (-45 dB)", res.NoiseFloorDB)
+	}
+	for range 50 {
+		if res, _ = vad.Analyze(noiseFrame(rng, -45)); res.Speech {
+			t.Fatalf("bruit de fond déclaré parole : %+v", res)
+		}
+	}
+	// Un micro coupé en cours de route ne fait pas non plus tomber le plancher.
+	for range 50 {
+		vad.Analyze(make([]int16, testFrameSamples))
+	}
+	if res, _ = vad.Analyze(noiseFrame(rng, -45)); res.Speech {
+		t.Errorf("bruit de fond déclaré parole après un silence numérique : %+v", res)
+	}
+}
+
+func TestVADNoiseFloorAdaptation(t *testing.T) {
+	for level := range vadLevels {
+		rng := rand.New(rand.NewPCG(1, 2))
+		vad, err := NewVAD(level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		analyze := func(levelDB float64) VADResult {
+			res, err := vad.Analyze(noiseFrame(rng, levelDB))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return res
+		}
+
+		// Le bruit baisse : le plancher suit vite (moins d'une seconde).
+		for range 50 {
+			analyze(-40)
+		}
+		var res VADResult
+		for range 50 {
+			res = analyze(-70)
+		}
+		if math.Abs(res.NoiseFloorDB-res.EnergyDB) > 3 {
+			t.Errorf("agressivité %d : plancher %.1f dB une seconde après la baisse du bruit à %.1f dB", level, res.NoiseFloorDB, res.EnergyDB)
+		}
+
+		// Le bruit monte durablement (ventilateur qui démarre) : d'abord pris pour de la parole,
+		// il finit absorbé par le plancher en moins d'une minute.
+		if res = analyze(-35); !res.Speech {
+			t.Errorf("agressivité %d : saut de bruit de 35 dB non détecté : %+v", level, res)
+		}
+		floor := res.NoiseFloorDB
+		speech := 0
+		for i := range 3000 {
+			res = analyze(-35)
+			if res.NoiseFloorDB < floor-0.5 {
+				t.Fatalf("agressivité %d, frame %d : le plancher redescend (%.1f → %.1f dB) sous un bruit constant", level, i, floor, res.NoiseFloorDB)
+			}
+			floor = res.NoiseFloorDB
+			if i >= 2900 && res.Speech {
+				speech++
+			}
+		}
+		if speech > 0 {
+			t.Errorf("agressivité %d : %d frames de bruit stationnaire encore déclarées parole après 58 s (plancher %.1f dB, énergie %.1f dB)",
+				level, speech, res.NoiseFloorDB, res.EnergyDB)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package wav
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"tars/audio/convert"
+)
+
+// Reader lit les échantillons d'un fichier WAV au fil de l'eau. L'en-tête est lu par NewReader ;
+// les chunks inconnus (LIST, fact, cue...) placés avant les données sont ignorés.
+type Reader struct {
+	r        io.Reader
+	format   Format
+	dataSize int64 // -1 si inconnue (WAV écrit en flux) : lecture jusqu'à EOF
+}
+
+// NewReader lit l'en-tête de r jusqu'au début du chunk "data".
+func NewReader(r io.Reader) (*Reader, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, fmt.Errorf("en-tête WAV: %w", err)
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return nil, errors.New("en-tête WAV: pas un fichier RIFF/WAVE")
+	}
+
+	var format Format
+	haveFmt := false
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return nil, fmt.Errorf("en-tête WAV: chunk \"data\" introuvable: %w", err)
+		}
+		id := string(chunk[0:4])
+		size := binary.LittleEndian.Uint32(chunk[4:8])
+
+		switch id {
+		case "fmt ":
+			if size < 16 || size > 1024 {
+				return nil, fmt.Errorf("en-tête WAV: chunk fmt de taille %d invalide", size)
+			}
+			body := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil, fmt.Errorf("en-tête WAV: %w", err)
+			}
+			f, err := parseFmt(body)
+			if err != nil {
+				return nil, err
+			}
+			format, haveFmt = f, true
+		case "data":
+			if !haveFmt {
+				return nil, errors.New("en-tête WAV: chunk data avant fmt")
+			}
+			wr := &Reader{r: r, format: format, dataSize: -1}
+			if size != 0 && size != unknownSize {
+				// Taille connue : on s'arrête à la fin du chunk (des chunks peuvent suivre).
+				wr.dataSize = int64(size)
+				wr.r = io.LimitReader(r, int64(size))
+			}
+			return wr, nil
+		default:
+			// Les chunks sont alignés sur 2 bytes.
+			if _, err := io.CopyN(io.Discard, r, int64(size)+int64(size%2)); err != nil {
+				return nil, fmt.Errorf("en-tête WAV: chunk %q: %w", id, err)
+			}
+		}
+	}
+}
+
+// parseFmt décode le chunk "fmt ", WAVE_FORMAT_EXTENSIBLE compris.
+func parseFmt(body []byte) (Format, error) {
+	tag := binary.LittleEndian.Uint16(body[0:2])
+	f := Format{
+		Channels:      int(binary.LittleEndian.Uint16(body[2:4])),
+		SampleRate:    int(binary.LittleEndian.Uint32(body[4:8])),
+		BitsPerSample: int(binary.LittleEndian.Uint16(body[14:16])),
+	}
+	if tag == formatExtensible {
+		if len(body) < 26 {
+			return Format{}, errors.New("WAV: chunk fmt extensible tronqué")
+		}
+		tag = binary.LittleEndian.Uint16(body[24:26]) // Deux premiers octets du GUID de sous-format
+	}
+	switch tag {
+	case formatPCM:
+	case formatFloat:
+		f.Float = true
+	default:
+		return Format{}, fmt.Errorf("WAV: code de format %#x non supporté (PCM ou float)", tag)
+	}
+	if err := f.Validate(); err != nil {
+		return Format{}, err
+	}
+	return f, nil
+}
+
+// Format retourne le format des échantillons stockés.
+func (r *Reader) Format() Format { return r.format }
+
+// DataSize retourne la taille des données annoncée par l'en-tête, -1 si elle est inconnue.
+func (r *Reader) DataSize() int64 { return r.dataSize }
+
+// Duration retourne la durée annoncée par l'en-tête, 0 si elle est inconnue.
+func (r *Reader) Duration() time.Duration {
+	if r.dataSize < 0 {
+		return 0
+	}
+	return r.format.Duration(r.dataSize)
+}
+
+// Read lit les données brutes, dans le format du fichier.
+func (r *Reader) Read(p []byte) (int, error) {
+	return r.r.Read(p)
+}
+
+// PCM retourne un lecteur des données converties au format du pipeline (voir Format.PCMFormat),
+// directement utilisable par convert. Ne pas mélanger avec des appels à Read.
+func (r *Reader) PCM() (io.Reader, convert.Format) {
+	pcm := r.format.PCMFormat()
+	switch {
+	case r.format.Float, r.format.BitsPerSample == 16:
+		return r.r, pcm // Déjà au bon format
+	case r.format.BitsPerSample == 8:
+		return &sampleReader{r: r.r, inSize: 1, outSize: 2, convert: uint8ToInt16}, pcm
+	case r.format.BitsPerSample == 24:
+		return &sampleReader{r: r.r, inSize: 3, outSize: 4, convert: int24ToFloat32}, pcm
+	default:
+		return &sampleReader{r: r.r, inSize: 4, outSize: 4, convert: int32ToFloat32}, pcm
+	}
+}
+
+// sampleReader convertit au fil de l'eau des échantillons de inSize bytes en outSize bytes.
+type sampleReader struct {
+	r       io.Reader
+	inSize  int
+	outSize int
+	convert func(dst, src []byte)
+	in      []byte // Bytes lus pas encore convertis (échantillon incomplet)
+	out     []byte // Bytes convertis pas encore servis
+	err     error
+}
+
+func (s *sampleReader) Read(p []byte) (int, error) {
+	for len(s.out) == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		buf := make([]byte, max(len(p)/s.outSize, 1)*s.inSize)
+		n := copy(buf, s.in)
+		m, err := s.r.Read(buf[n:])
+		buf = buf[:n+m]
+		s.err = err
+
+		whole := len(buf) - len(buf)%s.inSize
+		s.in = append(s.in[:0], buf[whole:]...)
+		s.out = make([]byte, whole/s.inSize*s.outSize)
+		for i := 0; i < whole/s.inSize; i++ {
+			s.convert(s.out[i*s.outSize:], buf[i*s.inSize:])
+		}
+	}
+	n := copy(p, s.out)
+	s.out = s.out[n:]
+	return n, nil
+}
+
+// Le PCM 8 bits est non signé, centré sur 128.
+func uint8ToInt16(dst, src []byte) {
+	binary.LittleEndian.PutUint16(dst, uint16(int16(int(src[0])-128)<<8))
+}
+
+func int24ToFloat32(dst, src []byte) {
+	v := int32(uint32(src[0])<<8|uint32(src[1])<<16|uint32(src[2])<<24) >> 8
+	binary.LittleEndian.PutUint32(dst, math.Float32bits(float32(v)/(1<<23)))
+}
+
+func int32ToFloat32(dst, src []byte) {
+	v := int32(binary.LittleEndian.Uint32(src))
+	binary.LittleEndian.PutUint32(dst, math.Float32bits(float32(float64(v)/(1<<31))))
+}
@@ -0,0 +1,139 @@
+// Package wav lit et écrit des fichiers RIFF/WAVE en flux : PCM entier 8, 16, 24 ou 32 bits et
+// float 32 bits, y compris au format WAVE_FORMAT_EXTENSIBLE. Utilisé par le STT (envoi des
+// énoncés), les sources et sorties fichier du pipeline, et les fixtures.
+package wav
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"tars/audio/convert"
+)
+
+// Codes de format du chunk "fmt ".
+const (
+	formatPCM        = 1
+	formatFloat      = 3
+	formatExtensible = 0xFFFE
+)
+
+// headerSize est la taille de l'en-tête écrit par ce package (RIFF + fmt de 16 bytes + en-tête data).
+const headerSize = 44
+
+// unknownSize marque une taille de chunk inconnue (WAV écrit en flux sur un pipe).
+const unknownSize = 0xFFFFFFFF
+
+// Format décrit les échantillons d'un fichier WAV tels qu'ils sont stockés.
+type Format struct {
+	SampleRate    int
+	Channels      int
+	BitsPerSample int  // 8, 16, 24 ou 32
+	Float         bool // IEEE float (32 bits) plutôt qu'entier ; le PCM 8 bits est non signé
+}
+
+// FormatOf retourne le format WAV correspondant à un format PCM du pipeline.
+func FormatOf(f convert.Format) Format {
+	return Format{
+		SampleRate:    f.SampleRate,
+		Channels:      f.Channels,
+		BitsPerSample: 8 * f.Sample.Size(),
+		Float:         f.Sample == convert.Float32,
+	}
+}
+
+// BytesPerFrame retourne la taille d'une frame (un échantillon par canal).
+func (f Format) BytesPerFrame() int {
+	return f.Channels * f.BitsPerSample / 8
+}
+
+// Duration convertit une taille de données en durée.
+func (f Format) Duration(n int64) time.Duration {
+	bps := int64(f.SampleRate * f.BytesPerFrame())
+	if bps <= 0 {
+		return 0
+	}
+	return time.Duration(n) * time.Second / time.Duration(bps)
+}
+
+// Validate vérifie que le format est lisible et inscriptible par ce package.
+func (f Format) Validate() error {
+	if f.SampleRate <= 0 || f.Channels <= 0 {
+		return fmt.Errorf("WAV: format invalide (%d Hz, %d canal(aux))", f.SampleRate, f.Channels)
+	}
+	switch {
+	case f.Float && f.BitsPerSample == 32:
+	case !f.Float && (f.BitsPerSample == 8 || f.BitsPerSample == 16 || f.BitsPerSample == 24 || f.BitsPerSample == 32):
+	default:
+		return fmt.Errorf("WAV: %s non supporté (entier 8/16/24/32 bits ou float 32 bits)", f.encoding())
+	}
+	return nil
+}
+
+// PCMFormat retourne le format du pipeline dans lequel Reader.PCM sert les échantillons :
+// int16 pour l'entier 8 et 16 bits, float32 pour l'entier 24 et 32 bits et le float.
+func (f Format) PCMFormat() convert.Format {
+	sample := convert.Float32
+	if !f.Float && f.BitsPerSample <= 16 {
+		sample = convert.Int16
+	}
+	return convert.Format{SampleRate: f.SampleRate, Channels: f.Channels, Sample: sample}
+}
+
+func (f Format) encoding() string {
+	if f.Float {
+		return fmt.Sprintf("float %d bits", f.BitsPerSample)
+	}
+	return fmt.Sprintf("entier %d bits", f.BitsPerSample)
+}
+
+func (f Format) String() string {
+	return fmt.Sprintf("%d Hz, %d canal(aux), %s", f.SampleRate, f.Channels, f.encoding())
+}
+
+// header construit un en-tête de 44 bytes pour dataSize bytes de données (unknownSize si inconnue).
+func header(f Format, dataSize uint32) []byte {
+	tag := uint16(formatPCM)
+	if f.Float {
+		tag = formatFloat
+	}
+	h := make([]byte, headerSize)
+	copy(h[0:], "RIFF")
+	binary.LittleEndian.PutUint32(h[4:], riffSize(dataSize))
+	copy(h[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(h[16:], 16) // Taille du chunk fmt
+	binary.LittleEndian.PutUint16(h[20:], tag)
+	binary.LittleEndian.PutUint16(h[22:], uint16(f.Channels))
+	binary.LittleEndian.PutUint32(h[24:], uint32(f.SampleRate))
+	binary.LittleEndian.PutUint32(h[28:], uint32(f.SampleRate*f.BytesPerFrame()))
+	binary.LittleEndian.PutUint16(h[32:], uint16(f.BytesPerFrame()))
+	binary.LittleEndian.PutUint16(h[34:], uint16(f.BitsPerSample))
+	copy(h[36:], "data")
+	binary.LittleEndian.PutUint32(h[40:], dataSize)
+	return h
+}
+
+// riffSize est la taille du chunk RIFF pour dataSize bytes de données (plus l'octet de bourrage).
+func riffSize(dataSize uint32) uint32 {
+	if dataSize == unknownSize {
+		return unknownSize
+	}
+	return uint32(min(uint64(dataSize)+uint64(dataSize%2)+headerSize-8, unknownSize-1))
+}
+
+// Encode enveloppe des données PCM (au format f) dans un fichier WAV complet en mémoire.
+func Encode(data []byte, f Format) ([]byte, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	if uint64(len(data)) >= unknownSize {
+		return nil, fmt.Errorf("WAV: %d bytes de données, au-delà de la limite de 4 Gio", len(data))
+	}
+	out := make([]byte, 0, headerSize+len(data)+1)
+	out = append(out, header(f, uint32(len(data)))...)
+	out = append(out, data...)
+	if len(data)%2 == 1 {
+		out = append(out, 0) // Les chunks RIFF sont alignés sur 2 bytes
+	}
+	return out, nil
+}
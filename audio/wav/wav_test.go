@@ -0,0 +1,290 @@
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/iotest"
+
+	"tars/audio/convert"
+)
+
+// chunk construit un chunk RIFF : identifiant, taille, données et octet de bourrage si la taille est impaire.
+func chunk(id string, body []byte) []byte {
+	out := binary.LittleEndian.AppendUint32([]byte(id), uint32(len(body)))
+	out = append(out, body...)
+	if len(body)%2 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+// riff construit un fichier WAV à partir de ses chunks.
+func riff(chunks ...[]byte) []byte {
+	body := []byte("WAVE")
+	for _, c := range chunks {
+		body = append(body, c...)
+	}
+	return chunk("RIFF", body)
+}
+
+// fmtChunk construit un chunk "fmt " de 16 bytes.
+func fmtChunk(tag uint16, channels, sampleRate, bits int) []byte {
+	b := binary.LittleEndian.AppendUint16(nil, tag)
+	b = binary.LittleEndian.AppendUint16(b, uint16(channels))
+	b = binary.LittleEndian.AppendUint32(b, uint32(sampleRate))
+	b = binary.LittleEndian.AppendUint32(b, uint32(sampleRate*channels*bits/8))
+	b = binary.LittleEndian.AppendUint16(b, uint16(channels*bits/8))
+	b = binary.LittleEndian.AppendUint16(b, uint16(bits))
+	return chunk("fmt ", b)
+}
+
+// extensibleChunk construit un chunk "fmt " WAVE_FORMAT_EXTENSIBLE de 40 bytes pour le sous-format subFormat.
+func extensibleChunk(subFormat uint16, channels, sampleRate, bits int) []byte {
+	b := fmtChunk(formatExtensible, channels, sampleRate, bits)[8:]
+	b = binary.LittleEndian.AppendUint16(b, 22)           // cbSize
+	b = binary.LittleEndian.AppendUint16(b, uint16(bits)) // wValidBitsPerSample
+	b = binary.LittleEndian.AppendUint32(b, 0x3)          // dwChannelMask : avant gauche et droite
+	b = binary.LittleEndian.AppendUint16(b, subFormat)    // GUID KSDATAFORMAT_SUBTYPE_*
+	b = append(b, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71)
+	return chunk("fmt ", b)
+}
+
+func int16s(v ...int16) []byte {
+	var b []byte
+	for _, s := range v {
+		b = binary.LittleEndian.AppendUint16(b, uint16(s))
+	}
+	return b
+}
+
+func float32s(v ...float32) []byte {
+	var b []byte
+	for _, s := range v {
+		b = binary.LittleEndian.AppendUint32(b, math.Float32bits(s))
+	}
+	return b
+}
+
+func TestReaderFormats(t *testing.T) {
+	list := chunk("LIST", []byte("INFOISFT\x05\x00\x00\x00tars\x00")) // Taille impaire : octet de bourrage
+	tests := []struct {
+		name    string
+		file    []byte
+		format  Format
+		pcm     []byte // Attendu de Reader.PCM
+		pcmType convert.SampleType
+	}{
+		{
+			name:    "entier 16 bits",
+			file:    riff(fmtChunk(formatPCM, 1, 16000, 16), chunk("data", int16s(1, -2, 32767))),
+			format:  Format{SampleRate: 16000, Channels: 1, BitsPerSample: 16},
+			pcm:     int16s(1, -2, 32767),
+			pcmType: convert.Int16,
+		},
+		{
+			name:    "entier 8 bits non signé, taille impaire",
+			file:    riff(fmtChunk(formatPCM, 1, 8000, 8), chunk("data", []byte{0, 128, 255})),
+			format:  Format{SampleRate: 8000, Channels: 1, BitsPerSample: 8},
+			pcm:     int16s(-32768, 0, 32512),
+			pcmType: convert.Int16,
+		},
+		{
+			name:    "entier 24 bits",
+			file:    riff(fmtChunk(formatPCM, 2, 48000, 24), chunk("data", []byte{0, 0, 0x40, 0, 0, 0xC0, 0xFF, 0xFF, 0x7F, 0, 0, 0x80})),
+			format:  Format{SampleRate: 48000, Channels: 2, BitsPerSample: 24},
+			pcm:     float32s(0.5, -0.5, 1-1.0/(1<<23), -1),
+			pcmType: convert.Float32,
+		},
+		{
+			name:    "entier 32 bits",
+			file:    riff(fmtChunk(formatPCM, 1, 44100, 32), chunk("data", []byte{0, 0, 0, 0x40, 0, 0, 0, 0x80})),
+			format:  Format{SampleRate: 44100, Channels: 1, BitsPerSample: 32},
+			pcm:     float32s(0.5, -1),
+			pcmType: convert.Float32,
+		},
+		{
+			name:    "float 32 bits",
+			file:    riff(fmtChunk(formatFloat, 1, 24000, 32), chunk("data", float32s(0.25, -0.75))),
+			format:  Format{SampleRate: 24000, Channels: 1, BitsPerSample: 32, Float: true},
+			pcm:     float32s(0.25, -0.75),
+			pcmType: convert.Float32,
+		},
+		{
+			name:    "extensible PCM",
+			file:    riff(extensibleChunk(formatPCM, 2, 44100, 16), chunk("data", int16s(100, -100))),
+			format:  Format{SampleRate: 44100, Channels: 2, BitsPerSample: 16},
+			pcm:     int16s(100, -100),
+			pcmType: convert.Int16,
+		},
+		{
+			name:    "extensible float",
+			file:    riff(extensibleChunk(formatFloat, 2, 48000, 32), chunk("data", float32s(0.5, -0.5))),
+			format:  Format{SampleRate: 48000, Channels: 2, BitsPerSample: 32, Float: true},
+			pcm:     float32s(0.5, -0.5),
+			pcmType: convert.Float32,
+		},
+		{
+			name: "chunks ignorés avant et après les données",
+			file: riff(list, fmtChunk(formatPCM, 1, 16000, 16), chunk("fact", []byte{3, 0, 0, 0}), chunk("junk", []byte{1, 2, 3}),
+				chunk("data", int16s(7, 8, 9)), list),
+			format:  Format{SampleRate: 16000, Channels: 1, BitsPerSample: 16},
+			pcm:     int16s(7, 8, 9),
+			pcmType: convert.Int16,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, mode := range []string{"bloc", "octet par octet"} {
+				var src io.Reader = bytes.NewReader(tt.file)
+				if mode == "octet par octet" {
+					src = iotest.OneByteReader(src) // Échantillons reçus en morceaux
+				}
+				r, err := NewReader(src)
+				if err != nil {
+					t.Fatalf("%s : %v", mode, err)
+				}
+				if r.Format() != tt.format {
+					t.Errorf("%s : format %v, attendu %v", mode, r.Format(), tt.format)
+				}
+				pcm, f := r.PCM()
+				if f != (convert.Format{SampleRate: tt.format.SampleRate, Channels: tt.format.Channels, Sample: tt.pcmType}) {
+					t.Errorf("%s : format PCM %v", mode, f)
+				}
+				got, err := io.ReadAll(pcm)
+				if err != nil {
+					t.Fatalf("%s : %v", mode, err)
+				}
+				if !bytes.Equal(got, tt.pcm) {
+					t.Errorf("%s : PCM % x, attendu % x", mode, got, tt.pcm)
+				}
+			}
+		})
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	tests := map[string][]byte{
+		"pas un WAV":            []byte("ID3\x04\x00\x00\x00\x00\x00\x00\x00\x00"),
+		"tronqué":               []byte("RIFF"),
+		"data avant fmt":        riff(chunk("data", int16s(1))),
+		"sans data":             riff(fmtChunk(formatPCM, 1, 16000, 16)),
+		"ADPCM":                 riff(fmtChunk(2, 1, 16000, 4), chunk("data", []byte{0})),
+		"float 64 bits":         riff(fmtChunk(formatFloat, 1, 16000, 64), chunk("data", make([]byte, 8))),
+		"extensible tronqué":    riff(chunk("fmt ", fmtChunk(formatExtensible, 1, 16000, 16)[8:]), chunk("data", int16s(1))),
+		"fmt trop court":        riff(chunk("fmt ", []byte{1, 0, 1, 0}), chunk("data", int16s(1))),
+		"zéro canal":            riff(fmtChunk(formatPCM, 0, 16000, 16), chunk("data", int16s(1))),
+		"chunk inconnu tronqué": append(riff(fmtChunk(formatPCM, 1, 16000, 16))[:36], "LIST\xff\x00\x00\x00abc"...),
+	}
+	for name, file := range tests {
+		if _, err := NewReader(bytes.NewReader(file)); err == nil {
+			t.Errorf("%s : pas d'erreur", name)
+		}
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	f := Format{SampleRate: 22050, Channels: 2, BitsPerSample: 16}
+	data := int16s(1, 2, 3, 4, -5, -6)
+	file, err := Encode(data, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewReader(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(r)
+	if r.Format() != f || r.DataSize() != int64(len(data)) || !bytes.Equal(got, data) {
+		t.Errorf("relu %v, %d bytes : % x", r.Format(), r.DataSize(), got)
+	}
+	if d := r.Duration(); d != f.Duration(int64(len(data))) {
+		t.Errorf("durée %v", d)
+	}
+}
+
+func TestWriterStream(t *testing.T) {
+	// Destination sans Seek (pipe) : les tailles restent « inconnues » et le lecteur va jusqu'à EOF.
+	var buf bytes.Buffer
+	f := Format{SampleRate: 16000, Channels: 1, BitsPerSample: 16}
+	w, err := NewWriter(&buf, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := int16s(10, 20, 30)
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(data); err == nil {
+		t.Error("écriture acceptée après Close")
+	}
+	if size := binary.LittleEndian.Uint32(buf.Bytes()[40:]); size != unknownSize {
+		t.Errorf("taille data %#x, attendu inconnue", size)
+	}
+
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(r)
+	if r.DataSize() != -1 || !bytes.Equal(got, data) {
+		t.Errorf("taille %d, données % x", r.DataSize(), got)
+	}
+}
+
+func TestWriterPatchesSizes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.wav")
+	f := Format{SampleRate: 8000, Channels: 1, BitsPerSample: 8}
+	w, err := Create(path, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, part := range [][]byte{{1, 2}, {3}} { // 3 bytes : octet de bourrage à la fin
+		if _, err := w.Write(part); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if w.Size() != 3 {
+		t.Errorf("Size() = %d", w.Size())
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(file) != headerSize+4 {
+		t.Fatalf("fichier de %d bytes, attendu %d", len(file), headerSize+4)
+	}
+	if size := binary.LittleEndian.Uint32(file[4:]); size != uint32(len(file)-8) {
+		t.Errorf("taille RIFF %d, attendu %d", size, len(file)-8)
+	}
+	if size := binary.LittleEndian.Uint32(file[40:]); size != 3 {
+		t.Errorf("taille data %d, attendu 3", size)
+	}
+
+	r, err := NewReader(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(r)
+	if r.Format() != f || r.DataSize() != 3 || !bytes.Equal(got, []byte{1, 2, 3}) {
+		t.Errorf("relu %v, %d bytes : % x", r.Format(), r.DataSize(), got)
+	}
+}
+
+func TestWriterInvalidFormat(t *testing.T) {
+	if _, err := NewWriter(io.Discard, Format{SampleRate: 16000, Channels: 1, BitsPerSample: 12}); err == nil {
+		t.Error("format 12 bits accepté")
+	}
+}
@@ -0,0 +1,117 @@
+package wav
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Writer écrit un fichier WAV en flux. L'en-tête est écrit immédiatement avec des tailles
+// provisoires, complétées par Close si la destination est un io.WriteSeeker (fichier) ;
+// sur un pipe, elles restent « inconnues » et les lecteurs lisent jusqu'à EOF.
+type Writer struct {
+	w      io.Writer
+	closer io.Closer // Fermé par Close (fichier ouvert par Create), sinon nil
+	format Format
+	start  int64 // Position de l'en-tête dans w (si w est un io.Seeker)
+	size   int64 // Données écrites
+	closed bool
+}
+
+// NewWriter écrit l'en-tête sur w. Close ne ferme pas w.
+func NewWriter(w io.Writer, f Format) (*Writer, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	var start int64
+	if seeker, ok := w.(io.Seeker); ok {
+		start, _ = seeker.Seek(0, io.SeekCurrent) // Échoue sur un pipe : sans importance, voir finish
+	}
+	if _, err := w.Write(header(f, unknownSize)); err != nil {
+		return nil, fmt.Errorf("écriture en-tête WAV: %w", err)
+	}
+	return &Writer{w: w, format: f, start: start}, nil
+}
+
+// Create crée (ou tronque) le fichier path et y écrit l'en-tête. Close ferme le fichier.
+func Create(path string, f Format) (*Writer, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("création fichier WAV: %w", err)
+	}
+	w, err := NewWriter(file, f)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	w.closer = file
+	return w, nil
+}
+
+// Format retourne le format des données attendues par Write.
+func (w *Writer) Format() Format { return w.format }
+
+// Size retourne la taille des données écrites jusqu'ici.
+func (w *Writer) Size() int64 { return w.size }
+
+// Write ajoute des données, déjà au format du fichier.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("WAV: écriture après Close")
+	}
+	if w.size+int64(len(p)) >= unknownSize {
+		return 0, errors.New("WAV: limite de 4 Gio atteinte")
+	}
+	n, err := w.w.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close termine le fichier : octet de bourrage éventuel puis tailles réelles dans l'en-tête.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	err := w.finish()
+	if w.closer != nil {
+		err = errors.Join(err, w.closer.Close())
+	}
+	return err
+}
+
+func (w *Writer) finish() error {
+	if w.size%2 == 1 {
+		if _, err := w.w.Write([]byte{0}); err != nil {
+			return fmt.Errorf("finalisation WAV: %w", err)
+		}
+	}
+	ws, ok := w.w.(io.WriteSeeker)
+	if !ok {
+		return nil // Tailles laissées inconnues
+	}
+	if _, err := ws.Seek(0, io.SeekCurrent); err != nil {
+		return nil // *os.File sur un pipe (stdout) : pas de retour en arrière possible
+	}
+	size := uint32(w.size)
+	var buf [4]byte
+	for _, field := range []struct {
+		offset int64
+		value  uint32
+	}{{4, riffSize(size)}, {headerSize - 4, size}} {
+		binary.LittleEndian.PutUint32(buf[:], field.value)
+		if _, err := ws.Seek(w.start+field.offset, io.SeekStart); err != nil {
+			return fmt.Errorf("finalisation WAV: %w", err)
+		}
+		if _, err := ws.Write(buf[:]); err != nil {
+			return fmt.Errorf("finalisation WAV: %w", err)
+		}
+	}
+	if _, err := ws.Seek(0, io.SeekEnd); err != nil {
+		return fmt.Errorf("finalisation WAV: %w", err)
+	}
+	return nil
+}
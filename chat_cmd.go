@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"tars/config"
+	"tars/orchestrator"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// runChat implémente `tars chat` : la conversation au clavier, sans micro (historique,
+// outils et persona identiques au mode vocal), pour mettre au point outils et prompt.
+func runChat(args []string) error {
+	fs := newFlagSet("chat", "")
+	speak := fs.Bool("speak", false, "prononce aussi les réponses sur la sortie audio")
+	output := fs.String("output", "", "sortie audio avec -speak : speaker (par défaut), un fichier .wav, ou null")
+	session := fs.String("session", "", "conversation à reprendre : last (par défaut), new, ou l'ID d'une session")
+	verbose := fs.Bool("v", false, "affiche les logs sur la sortie d'erreur")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return fmt.Errorf("argument inattendu %q", fs.Arg(0))
+	}
+
+	if *speak {
+		config.LoadConfigFor(config.ModuleLLM, config.ModuleTTS)
+	} else {
+		config.LoadConfigFor(config.ModuleLLM)
+	}
+	if *output != "" {
+		config.AudioOutput = *output
+	}
+	if *session != "" {
+		config.HistorySession = *session
+	}
+	// Les logs du pipeline se mêleraient à la conversation : masqués sauf avec -v.
+	if !*verbose {
+		log.SetOutput(io.Discard)
+		defer log.SetOutput(os.Stderr)
+	}
+
+	terminate, err := initPortAudio(false, *speak)
+	if err != nil {
+		return err
+	}
+	defer terminate()
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	orch, err := orchestrator.NewChat(openai.NewClient(config.OpenAIAPIKey), os.Stdin, os.Stdout, *speak)
+	if err != nil {
+		return fmt.Errorf("initialisation: %w", err)
+	}
+	if session := orch.Conversation().Session(); session != "" {
+		fmt.Printf("Session %s. Ctrl+D pour quitter.\n", session)
+	}
+	return orch.Run(ctx)
+}
@@ -1,26 +1,128 @@
 package config
 
-import "os"
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
 
 var (
 	OpenAIAPIKey       string
-	SampleRate         = 16000 // Pour la CAPTURE micro (Whisper)
-	Channels           = 1     // Pour la CAPTURE micro
+	SampleRate         = 16000 // Du pipeline VAD/STT (Whisper) ; le micro est rééchantillonné s'il ne l'accepte pas
+	Channels           = 1     // Frames envoyées au VAD/STT : toujours mono, voir CaptureChannels/CaptureDownmix
 	BitDepth           = 16    // Pour la CAPTURE micro (PCM 16-bit)
 	VADFrameDurationMs = 20    // ms, pour VAD (avec 16kHz, donne 320 samples / 640 bytes)
 	VADSilenceFrames   = 25    // Nombre de frames silence avant de considérer fin de parole (25 * 20ms = 500ms)
 	VADSpeechFrames    = 3     // Nombre de frames de parole avant de commencer à enregistrer (3 * 20ms = 60ms)
 	VADAggressiveness  = 2     // 0 (least aggressive) à 3 (most aggressive)
+	VADPreRollMs       = 300   // Audio conservé avant le début de parole détecté (pour ne pas couper la première syllabe)
+	VADMaxUtteranceMs  = 30000 // Durée maximale d'un énoncé avant découpe forcée
+
+	// Source audio du pipeline : "" ou "mic" pour le micro (PortAudio), un fichier .wav à rejouer,
+	// ou "-" pour du PCM brut 16 bits little-endian sur l'entrée standard (StdinSampleRate, StdinChannels).
+	// Une source finie arrête TARS une fois la dernière réponse prononcée. Surchargée par l'option -input.
+	AudioInput         = ""
+	AudioInputRealtime = true  // Fichier rejoué au rythme réel (sinon aussi vite que le pipeline le consomme)
+	AudioInputLoop     = false // Fichier rejoué en boucle
+	StdinSampleRate    = 16000
+	StdinChannels      = 1
+
+	// Sortie audio : "" ou "speaker" pour les haut-parleurs, un fichier .wav où écrire ce que dit
+	// le bot, ou "null" (rien n'est joué, au rythme réel). Surchargée par l'option -output.
+	AudioOutput = ""
+
+	// Périphériques audio : vide pour celui par défaut, sinon un index ou une partie du nom
+	// (insensible à la casse) tels qu'affichés par `tars devices`.
+	InputDevice  = ""
+	OutputDevice = "" // Défini : la lecture passe par PortAudio au lieu de oto (sortie système par défaut)
+
+	// Micros stéréo ou multi-micros : le périphérique est ouvert à CaptureChannels canaux
+	// puis ramené au mono pour le VAD selon CaptureDownmix.
+	CaptureChannels = 0         // 0 : tous les canaux du périphérique
+	CaptureDownmix  = "average" // average (moyenne), select (CaptureChannel) ou loudest (canal le plus fort, par frame)
+	CaptureChannel  = 0         // Canal gardé en mode select (à partir de 0)
+
+	// Fréquence d'ouverture du micro, rééchantillonnée vers SampleRate.
+	// 0 : SampleRate si le périphérique l'accepte, sinon sa fréquence par défaut (souvent 44100 ou 48000).
+	CaptureSampleRate = 0
+
+	// STT : "openai" (cloud) ou "whisper.cpp" (serveur local, voir WhisperCppURL)
+	STTProvider   = "openai"
+	STTLanguage   = ""                      // "fr", "en"... vide : détection automatique
+	WhisperCppURL = "http://127.0.0.1:8080" // Adresse du serveur HTTP whisper.cpp
+
+	// TTS : "openai" (cloud) ou "piper" (binaire local, voir PiperPath/PiperModel)
+	TTSProvider     = "openai"
+	TTSVoice        = "alloy" // Voix OpenAI : alloy, echo, fable, onyx, nova, shimmer
+	OpenAITTSFormat = "pcm"   // pcm (latence minimale), mp3 ou opus (moins de bande passante), wav
+	PiperPath       = "piper" // Chemin du binaire piper (cherché dans le PATH)
+	PiperModel      = ""      // Chemin de la voix .onnx (le .onnx.json doit être à côté)
+	PiperSpeaker    = -1      // Locuteur des modèles multi-voix, -1 : par défaut
+
+	TTSChunkMs = 20 // Taille max des morceaux de PCM envoyés au player pendant la synthèse
+
+	// Format de sortie du player (PCM 16-bit). Le TTS OpenAI sort à 24kHz mono, Piper au sample rate
+	// du modèle (souvent 22050 Hz) : le player rééchantillonne et remixe à la volée si besoin.
+	PlayerSampleRate = 24000
+	PlayerChannels   = 1
 
-	// Note: Le TTS OpenAI (PCM) sort à 24kHz, 1 canal, 16-bit.
-	// Le AudioPlayer doit être configuré avec ces valeurs.
-	TTSSampleRate = 24000
-	TTSChannels   = 1
+	// LLM : "openai", "openai-compatible" (LLMBaseURL, ex. llama.cpp/LM Studio/vLLM) ou "ollama" (API native, OllamaURL)
+	LLMProvider    = "openai"
+	LLMModel       = "gpt-3.5-turbo"
+	LLMTemperature = float32(0.7) // Négative : valeur par défaut du backend ; 0 est une valeur valide (réponses déterministes)
+	LLMMaxTokens   = 0            // 0 : pas de limite
+	LLMBaseURL     = ""           // Pour "openai-compatible", ex. http://127.0.0.1:1234/v1
+	LLMAPIKey      = ""           // Pour "openai-compatible" (souvent ignorée par les serveurs locaux)
+	OllamaURL      = "http://127.0.0.1:11434"
+
+	LLMStreaming      = true  // Envoie la réponse au TTS phrase par phrase (latence de la première phrase)
+	MaxToolIterations = 5     // Nombre max d'allers-retours tool calls → résultats avant de forcer une réponse texte
+	ToolCallTimeoutMs = 10000 // Durée max d'exécution d'un tool call, 0 : pas de limite
+
+	// Persona : prompt système rendu avant chaque requête au LLM à partir d'un template Go
+	// (text/template, voir persona/default.tmpl pour les variables disponibles).
+	PromptTemplate = ""      // Chemin du template, vide : template intégré
+	Locale         = "fr-FR" // Langue des réponses et format de la date donnés au modèle
+	UserName       = ""      // Prénom de l'utilisateur, vide : inconnu
+	HumourSetting  = 75      // %, comme TARS dans Interstellar
+	HonestySetting = 90      // %
+
+	// Fenêtre de contexte : au-delà de LLMContextBudget tokens (comptés localement avec le tokenizer
+	// du modèle), les échanges les plus anciens sont remplacés par un résumé généré par le LLM.
+	// Les LLMContextKeepTurns derniers tours de parole restent toujours intacts.
+	LLMContextBudget    = 6000 // 0 : historique complet envoyé à chaque requête
+	LLMContextKeepTurns = 4
+
+	// Historique persistant (base bbolt) : chaque message est enregistré avec sa date et sa session.
+	HistoryPath    = "tars.db" // Vide : historique en mémoire seulement, perdu à l'arrêt
+	HistorySession = "last"    // "last" reprend la dernière session, "new" en commence une, ou l'ID d'une session
+
+	PlayerBufferMs = 40   // Latence de sortie audio, borne aussi le délai d'une interruption
+	BargeInEnabled = true // L'utilisateur peut couper la parole au bot (casque conseillé : sans AEC, le micro entend les haut-parleurs)
 )
 
+// Module désigne un module du pipeline dont le backend peut être l'API OpenAI.
+type Module int
+
+const (
+	ModuleSTT Module = iota
+	ModuleLLM
+	ModuleTTS
+)
+
+// LoadConfig charge la config de l'assistant complet.
 func LoadConfig() {
+	LoadConfigFor(ModuleSTT, ModuleLLM, ModuleTTS)
+}
+
+// LoadConfigFor charge la config d'une commande qui n'utilise qu'une partie des modules
+// (tars say, transcribe, chat...) : OPENAI_API_KEY n'est exigée que si l'un d'eux passe par OpenAI.
+func LoadConfigFor(modules ...Module) {
+	LoadEnv()
+
 	OpenAIAPIKey = os.Getenv("OPENAI_API_KEY")
-	if OpenAIAPIKey == "" {
+	if OpenAIAPIKey == "" && UsesOpenAI(modules...) {
 		// Pour tests locaux, on peut le hardcoder mais ce n'est pas recommandé
 		// OpenAIAPIKey = "votre_cle_api_ici"
 		// if OpenAIAPIKey == "votre_cle_api_ici" {
@@ -30,3 +132,100 @@ func LoadConfig() {
 		// }
 	}
 }
+
+// LoadEnv applique les surcharges par variables d'environnement, sans exiger de clé API :
+// LoadConfig l'appelle, les commandes utilitaires (historique...) peuvent s'en contenter.
+func LoadEnv() {
+	// Surcharges par variables d'environnement, pour changer de backend sans recompiler.
+	envString("TARS_AUDIO_INPUT", &AudioInput)
+	envString("TARS_AUDIO_OUTPUT", &AudioOutput)
+	envString("TARS_INPUT_DEVICE", &InputDevice)
+	envString("TARS_OUTPUT_DEVICE", &OutputDevice)
+	envInt("TARS_CAPTURE_CHANNELS", &CaptureChannels)
+	envString("TARS_CAPTURE_DOWNMIX", &CaptureDownmix)
+	envInt("TARS_CAPTURE_CHANNEL", &CaptureChannel)
+	envInt("TARS_CAPTURE_SAMPLE_RATE", &CaptureSampleRate)
+	envString("TARS_STT_PROVIDER", &STTProvider)
+	envString("TARS_STT_LANGUAGE", &STTLanguage)
+	envString("TARS_WHISPER_CPP_URL", &WhisperCppURL)
+	envString("TARS_LLM_PROVIDER", &LLMProvider)
+	envString("TARS_LLM_MODEL", &LLMModel)
+	envFloat32("TARS_LLM_TEMPERATURE", &LLMTemperature)
+	envInt("TARS_LLM_MAX_TOKENS", &LLMMaxTokens)
+	envString("TARS_LLM_BASE_URL", &LLMBaseURL)
+	envString("TARS_LLM_API_KEY", &LLMAPIKey)
+	envString("TARS_OLLAMA_URL", &OllamaURL)
+	envInt("TARS_LLM_CONTEXT_BUDGET", &LLMContextBudget)
+	envInt("TARS_TOOL_CALL_TIMEOUT_MS", &ToolCallTimeoutMs)
+	envString("TARS_PROMPT_TEMPLATE", &PromptTemplate)
+	envString("TARS_LOCALE", &Locale)
+	envString("TARS_USER_NAME", &UserName)
+	envInt("TARS_HUMOUR", &HumourSetting)
+	envInt("TARS_HONESTY", &HonestySetting)
+	envInt("TARS_LLM_CONTEXT_KEEP_TURNS", &LLMContextKeepTurns)
+	envString("TARS_TTS_PROVIDER", &TTSProvider)
+	envString("TARS_TTS_VOICE", &TTSVoice)
+	envString("TARS_OPENAI_TTS_FORMAT", &OpenAITTSFormat)
+	envString("TARS_PIPER_PATH", &PiperPath)
+	envString("TARS_PIPER_MODEL", &PiperModel)
+	envInt("TARS_PIPER_SPEAKER", &PiperSpeaker)
+	envInt("TARS_PLAYER_SAMPLE_RATE", &PlayerSampleRate)
+	envInt("TARS_PLAYER_CHANNELS", &PlayerChannels)
+	envString("TARS_HISTORY_PATH", &HistoryPath)
+	envString("TARS_HISTORY_SESSION", &HistorySession)
+}
+
+// UsesOpenAI indique si un des modules a besoin de l'API OpenAI (et donc de OPENAI_API_KEY).
+func UsesOpenAI(modules ...Module) bool {
+	for _, m := range modules {
+		switch {
+		case m == ModuleSTT && Provider(STTProvider) == "openai",
+			m == ModuleLLM && Provider(LLMProvider) == "openai",
+			m == ModuleTTS && Provider(TTSProvider) == "openai":
+			return true
+		}
+	}
+	return false
+}
+
+// Provider normalise le nom d'un provider (STTProvider, LLMProvider, TTSProvider) tel que
+// le lisent stt.New, llm.New et tts.New : casse et espaces ignorés, vide pour OpenAI.
+func Provider(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "openai"
+	}
+	return name
+}
+
+func envString(name string, dst *string) {
+	if v, ok := os.LookupEnv(name); ok {
+		*dst = v
+	}
+}
+
+func envInt(name string, dst *int) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("Config: %s=%q ignorée (entier attendu)", name, v)
+		return
+	}
+	*dst = n
+}
+
+func envFloat32(name string, dst *float32) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return
+	}
+	f, err := strconv.ParseFloat(v, 32)
+	if err != nil {
+		log.Printf("Config: %s=%q ignorée (nombre attendu)", name, v)
+		return
+	}
+	*dst = float32(f)
+}
@@ -0,0 +1,27 @@
+package config
+
+import "testing"
+
+func TestUsesOpenAI(t *testing.T) {
+	defer func(stt, llm, tts string) { STTProvider, LLMProvider, TTSProvider = stt, llm, tts }(STTProvider, LLMProvider, TTSProvider)
+
+	tests := []struct {
+		stt, llm, tts string
+		modules       []Module
+		want          bool
+	}{
+		{"whisper.cpp", "ollama", "piper", []Module{ModuleSTT, ModuleLLM, ModuleTTS}, false},
+		{"whisper.cpp", "OpenAI", "piper", []Module{ModuleLLM}, true},
+		{"whisper.cpp", " openai ", "piper", []Module{ModuleLLM}, true},
+		{"", "ollama", "piper", []Module{ModuleSTT}, true}, // Vide : OpenAI par défaut
+		{"OPENAI", "ollama", "piper", []Module{ModuleLLM, ModuleTTS}, false},
+		{"whisper.cpp", "ollama", "OpenAI", []Module{ModuleTTS}, true},
+		{"openai", "openai", "openai", nil, false},
+	}
+	for _, tt := range tests {
+		STTProvider, LLMProvider, TTSProvider = tt.stt, tt.llm, tt.tts
+		if got := UsesOpenAI(tt.modules...); got != tt.want {
+			t.Errorf("STT %q, LLM %q, TTS %q, modules %v : %v, attendu %v", tt.stt, tt.llm, tt.tts, tt.modules, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"tars/audio"
+
+	"github.com/gordonklaus/portaudio"
+)
+
+// runDevices implémente `tars devices` : les périphériques utilisables pour InputDevice/OutputDevice.
+func runDevices(args []string) error {
+	fs := newFlagSet("devices", "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := portaudio.Initialize(); err != nil {
+		return fmt.Errorf("initialisation PortAudio: %w", err)
+	}
+	defer portaudio.Terminate()
+	return audio.PrintDevices(os.Stdout)
+}
@@ -5,10 +5,17 @@ go 1.24.3
 require (
 	github.com/ebitengine/oto/v3 v3.3.3
 	github.com/gordonklaus/portaudio v0.0.0-20250206071425-98a94950218b
+	github.com/hajimehoshi/go-mp3 v0.3.4
+	github.com/pion/opus v0.1.0
+	github.com/pkoukk/tiktoken-go v0.1.8
+	github.com/pkoukk/tiktoken-go-loader v0.0.2
 	github.com/sashabaranov/go-openai v1.40.1
+	go.etcd.io/bbolt v1.4.3
 )
 
 require (
+	github.com/dlclark/regexp2 v1.10.0 // indirect
 	github.com/ebitengine/purego v0.8.0 // indirect
-	golang.org/x/sys v0.25.0 // indirect
+	github.com/google/uuid v1.3.0 // indirect
+	golang.org/x/sys v0.29.0 // indirect
 )
@@ -0,0 +1,45 @@
+package history
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// PrintSessions écrit la liste des sessions sous forme de tableau.
+func PrintSessions(w io.Writer, sessions []Session) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SESSION\tDÉBUT\tDERNIER MESSAGE\tMESSAGES\tSUJET")
+	for _, s := range sessions {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n",
+			s.ID, s.Started.Format("2006-01-02 15:04"), s.Updated.Format("2006-01-02 15:04"), s.Messages, s.Title)
+	}
+	return tw.Flush()
+}
+
+// PrintEntries écrit une conversation, un message par ligne, appels et résultats d'outils compris.
+func PrintEntries(w io.Writer, entries []Entry) error {
+	for _, e := range entries {
+		m := e.Message
+		stamp := e.Time.Format("15:04:05")
+		if m.Content != "" || len(m.ToolCalls) == 0 {
+			who := m.Role
+			if m.Role == openai.ChatMessageRoleTool {
+				who = "outil " + m.ToolCallID
+			}
+			if _, err := fmt.Fprintf(w, "[%s] %s: %s\n", stamp, who, m.Content); err != nil {
+				return err
+			}
+		}
+		for _, call := range m.ToolCalls {
+			args := strings.TrimSpace(call.Function.Arguments)
+			if _, err := fmt.Fprintf(w, "[%s] %s → %s(%s) [%s]\n", stamp, m.Role, call.Function.Name, args, call.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,327 @@
+// Package history enregistre les conversations sur disque (base bbolt embarquée) : chaque
+// transcription, réponse, appel d'outil et résultat, horodaté et rattaché à une session,
+// pour reprendre la dernière conversation au redémarrage et la consulter depuis la ligne de commande.
+package history
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Buckets : sessions (ID → Session en JSON) et messages (un sous-bucket par session,
+// position du message → Entry en JSON).
+var (
+	sessionsBucket = []byte("sessions")
+	messagesBucket = []byte("messages")
+)
+
+// ErrNotFound est retourné pour une session inconnue.
+var ErrNotFound = errors.New("session introuvable")
+
+// Session décrit une conversation enregistrée.
+type Session struct {
+	ID       string    `json:"id"`
+	Started  time.Time `json:"started"`
+	Updated  time.Time `json:"updated"`
+	Title    string    `json:"title"` // Début de la première phrase de l'utilisateur
+	Messages int       `json:"messages"`
+
+	// Résumé des SummaryCovers premiers messages, tenu à jour par la fenêtre de contexte du LLM
+	// pour qu'une session reprise n'ait pas à être résumée à nouveau.
+	Summary       string `json:"summary,omitempty"`
+	SummaryCovers int    `json:"summary_covers,omitempty"`
+}
+
+// Entry est un message de l'historique avec sa date d'enregistrement.
+type Entry struct {
+	Time    time.Time                    `json:"time"`
+	Message openai.ChatCompletionMessage `json:"message"`
+}
+
+// Store est la base des conversations. Il est sûr pour un usage concurrent.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open ouvre (ou crée) la base path. Une seule instance de TARS peut l'ouvrir à la fois.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		if errors.Is(err, bolt.ErrTimeout) {
+			return nil, fmt.Errorf("historique %s déjà ouvert par un autre processus", path)
+		}
+		return nil, fmt.Errorf("ouverture historique %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(sessionsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(messagesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initialisation historique %s: %w", path, err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close ferme la base.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// NewSession crée une session vide et retourne son ID (horodatage, triable).
+func (s *Store) NewSession() (string, error) {
+	now := time.Now()
+	var id string
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		sessions := tx.Bucket(sessionsBucket)
+		base := now.Format("20060102-150405")
+		id = base
+		for n := 2; sessions.Get([]byte(id)) != nil; n++ {
+			id = fmt.Sprintf("%s-%d", base, n)
+		}
+		if _, err := tx.Bucket(messagesBucket).CreateBucket([]byte(id)); err != nil {
+			return err
+		}
+		return putSession(sessions, Session{ID: id, Started: now, Updated: now})
+	})
+	if err != nil {
+		return "", fmt.Errorf("création session: %w", err)
+	}
+	return id, nil
+}
+
+// Sessions retourne toutes les sessions, de la plus ancienne à la plus récente.
+func (s *Store) Sessions() ([]Session, error) {
+	var out []Session
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).ForEach(func(_, v []byte) error {
+			var sess Session
+			if err := json.Unmarshal(v, &sess); err != nil {
+				return err
+			}
+			out = append(out, sess)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("lecture des sessions: %w", err)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Started.Before(out[j].Started) })
+	return out, nil
+}
+
+// LastSession retourne la session mise à jour le plus récemment. ok est faux si la base est vide.
+func (s *Store) LastSession() (sess Session, ok bool, err error) {
+	sessions, err := s.Sessions()
+	if err != nil {
+		return Session{}, false, err
+	}
+	for _, candidate := range sessions {
+		if !ok || candidate.Updated.After(sess.Updated) {
+			sess, ok = candidate, true
+		}
+	}
+	return sess, ok, nil
+}
+
+// Session retourne la session id.
+func (s *Store) Session(id string) (Session, error) {
+	var sess Session
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		sess, err = getSession(tx.Bucket(sessionsBucket), id)
+		return err
+	})
+	return sess, err
+}
+
+// Entries retourne les messages de la session id, dans l'ordre.
+func (s *Store) Entries(id string) ([]Entry, error) {
+	var out []Entry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(messagesBucket).Bucket([]byte(id))
+		if b == nil {
+			return ErrNotFound
+		}
+		return b.ForEach(func(_, v []byte) error {
+			var e Entry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			out = append(out, e)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("session %s: %w", id, err)
+	}
+	return out, nil
+}
+
+// Put enregistre msgs à partir de la position index de la session id, en une seule transaction :
+// un nouveau message à la fin de l'historique, sinon le remplacement d'un message existant
+// (réponse complétée phrase après phrase, tronquée par une interruption...), dont la date
+// d'origine est conservée. Un aller-retour d'outils (tool calls et résultats) est enregistré
+// en entier ou pas du tout.
+func (s *Store) Put(id string, index int, msgs ...openai.ChatCompletionMessage) error {
+	now := time.Now()
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		sessions := tx.Bucket(sessionsBucket)
+		sess, err := getSession(sessions, id)
+		if err != nil {
+			return err
+		}
+		b := tx.Bucket(messagesBucket).Bucket([]byte(id))
+		if b == nil {
+			return ErrNotFound
+		}
+
+		for i, msg := range msgs {
+			key := indexKey(index + i)
+			entry := Entry{Time: now, Message: msg}
+			if old := b.Get(key); old != nil {
+				var prev Entry
+				if err := json.Unmarshal(old, &prev); err == nil {
+					entry.Time = prev.Time
+				}
+			} else if index+i != sess.Messages {
+				return fmt.Errorf("position %d hors de l'historique (%d messages)", index+i, sess.Messages)
+			} else {
+				sess.Messages++
+			}
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(key, data); err != nil {
+				return err
+			}
+
+			if sess.Title == "" && msg.Role == openai.ChatMessageRoleUser {
+				sess.Title = title(msg.Content)
+			}
+		}
+		sess.Updated = now
+		return putSession(sessions, sess)
+	})
+	if err != nil {
+		return fmt.Errorf("enregistrement message (session %s): %w", id, err)
+	}
+	return nil
+}
+
+// Truncate supprime les messages de la session id à partir de la position n.
+func (s *Store) Truncate(id string, n int) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		sessions := tx.Bucket(sessionsBucket)
+		sess, err := getSession(sessions, id)
+		if err != nil {
+			return err
+		}
+		b := tx.Bucket(messagesBucket).Bucket([]byte(id))
+		if b == nil {
+			return ErrNotFound
+		}
+		for i := n; i < sess.Messages; i++ {
+			if err := b.Delete(indexKey(i)); err != nil {
+				return err
+			}
+		}
+		sess.Messages = min(sess.Messages, n)
+		if sess.SummaryCovers > n {
+			sess.Summary, sess.SummaryCovers = "", 0 // Le résumé couvre des messages supprimés
+		}
+		sess.Updated = time.Now()
+		return putSession(sessions, sess)
+	})
+	if err != nil {
+		return fmt.Errorf("troncature session %s: %w", id, err)
+	}
+	return nil
+}
+
+// SetSummary enregistre le résumé de la session id, qui couvre ses covered premiers messages.
+func (s *Store) SetSummary(id, summary string, covered int) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		sessions := tx.Bucket(sessionsBucket)
+		sess, err := getSession(sessions, id)
+		if err != nil {
+			return err
+		}
+		sess.Summary, sess.SummaryCovers = summary, covered
+		return putSession(sessions, sess)
+	})
+	if err != nil {
+		return fmt.Errorf("enregistrement résumé (session %s): %w", id, err)
+	}
+	return nil
+}
+
+// Delete supprime la session id et ses messages.
+func (s *Store) Delete(id string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		sessions := tx.Bucket(sessionsBucket)
+		if sessions.Get([]byte(id)) == nil {
+			return ErrNotFound
+		}
+		if err := sessions.Delete([]byte(id)); err != nil {
+			return err
+		}
+		err := tx.Bucket(messagesBucket).DeleteBucket([]byte(id))
+		if errors.Is(err, bolt.ErrBucketNotFound) {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("suppression session %s: %w", id, err)
+	}
+	return nil
+}
+
+func getSession(b *bolt.Bucket, id string) (Session, error) {
+	data := b.Get([]byte(id))
+	if data == nil {
+		return Session{}, ErrNotFound
+	}
+	var sess Session
+	if err := json.Unmarshal(data, &sess); err != nil {
+		return Session{}, err
+	}
+	return sess, nil
+}
+
+func putSession(b *bolt.Bucket, sess Session) error {
+	data, err := json.Marshal(sess)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(sess.ID), data)
+}
+
+// indexKey encode une position en big-endian pour que bbolt itère dans l'ordre.
+func indexKey(index int) []byte {
+	var k [8]byte
+	binary.BigEndian.PutUint64(k[:], uint64(index))
+	return k[:]
+}
+
+// title résume le premier message de l'utilisateur pour la liste des sessions.
+func title(text string) string {
+	const maxRunes = 60
+	runes := []rune(text)
+	if len(runes) <= maxRunes {
+		return text
+	}
+	return string(runes[:maxRunes]) + "…"
+}
@@ -0,0 +1,210 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func openTestStore(t *testing.T, path string) *Store {
+	t.Helper()
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func user(text string) openai.ChatCompletionMessage {
+	return openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: text}
+}
+
+func assistant(text string) openai.ChatCompletionMessage {
+	return openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: text}
+}
+
+// contents retourne le texte des messages de la session id.
+func contents(t *testing.T, s *Store, id string) []string {
+	t.Helper()
+	entries, err := s.Entries(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make([]string, len(entries))
+	for i, e := range entries {
+		out[i] = e.Message.Content
+	}
+	return out
+}
+
+func TestStoreSaveAndReloadOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tars.db")
+	s := openTestStore(t, path)
+	id, err := s.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Plus de 256 messages : l'ordre ne doit pas dépendre d'un tri lexicographique des positions.
+	const n = 300
+	for i := range n {
+		if err := s.Put(id, i, user(fmt.Sprint(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Put(id, n+1, user("trou")); err == nil {
+		t.Error("message accepté au-delà de la fin de l'historique")
+	}
+	// Remplacement du dernier message et ajout en une transaction : la date d'origine est gardée.
+	before, _ := s.Entries(id)
+	if err := s.Put(id, n-1, assistant("remplacé"), assistant("ajouté")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s = openTestStore(t, path)
+	defer s.Close()
+	got := contents(t, s, id)
+	if len(got) != n+1 || got[n-1] != "remplacé" || got[n] != "ajouté" {
+		t.Fatalf("%d messages relus, fin %v", len(got), got[max(0, len(got)-2):])
+	}
+	for i := range n - 1 {
+		if got[i] != fmt.Sprint(i) {
+			t.Fatalf("message %d = %q, attendu dans l'ordre d'enregistrement", i, got[i])
+		}
+	}
+	after, _ := s.Entries(id)
+	if !after[n-1].Time.Equal(before[n-1].Time) {
+		t.Errorf("date du message remplacé changée : %v → %v", before[n-1].Time, after[n-1].Time)
+	}
+
+	sess, err := s.Session(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sess.Messages != n+1 || sess.Title != "0" {
+		t.Errorf("session relue %+v", sess)
+	}
+}
+
+func TestStoreSessions(t *testing.T) {
+	s := openTestStore(t, filepath.Join(t.TempDir(), "tars.db"))
+	defer s.Close()
+
+	if _, ok, err := s.LastSession(); ok || err != nil {
+		t.Errorf("base vide : LastSession = %v, %v", ok, err)
+	}
+
+	var ids []string
+	for range 3 {
+		id, err := s.NewSession()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	long := strings.Repeat("a", 70)
+	if err := s.Put(ids[0], 0, user(long)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(ids[1], 0, assistant("Bonjour."), user("Quelle heure est-il ?")); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := s.Sessions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 3 {
+		t.Fatalf("%d sessions, attendu 3", len(sessions))
+	}
+	for i, sess := range sessions {
+		if sess.ID != ids[i] {
+			t.Errorf("session %d : %s, attendu %s (ordre de création)", i, sess.ID, ids[i])
+		}
+	}
+	if ids[0] == ids[1] || ids[1] == ids[2] {
+		t.Errorf("IDs de session en double : %v", ids) // Créées dans la même seconde
+	}
+	if sessions[0].Title != strings.Repeat("a", 60)+"…" || sessions[1].Title != "Quelle heure est-il ?" || sessions[2].Title != "" {
+		t.Errorf("titres %q, %q, %q", sessions[0].Title, sessions[1].Title, sessions[2].Title)
+	}
+
+	// La dernière session est la plus récemment mise à jour, pas la dernière créée.
+	if err := s.Put(ids[0], 1, assistant("Reprise.")); err != nil {
+		t.Fatal(err)
+	}
+	if last, ok, err := s.LastSession(); !ok || err != nil || last.ID != ids[0] {
+		t.Errorf("LastSession = %s, %v, %v, attendu %s", last.ID, ok, err, ids[0])
+	}
+
+	if err := s.Delete(ids[1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Session(ids[1]); !errors.Is(err, ErrNotFound) {
+		t.Errorf("session supprimée : %v", err)
+	}
+	if _, err := s.Entries(ids[1]); !errors.Is(err, ErrNotFound) {
+		t.Errorf("messages de la session supprimée : %v", err)
+	}
+	if err := s.Put("inconnue", 0, user("?")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Put sur une session inconnue : %v", err)
+	}
+}
+
+func TestStoreTruncateAndSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tars.db")
+	s := openTestStore(t, path)
+	id, err := s.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(id, 0, user("1"), assistant("2"), user("3"), assistant("4")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetSummary(id, "résumé", 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Truncate(id, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(id, 3, assistant("4 bis")); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	// Réouverture du même fichier : tout est relu depuis le disque.
+	s = openTestStore(t, path)
+	defer s.Close()
+	if got := strings.Join(contents(t, s, id), ","); got != "1,2,3,4 bis" {
+		t.Errorf("messages %s", got)
+	}
+	sess, _ := s.Session(id)
+	if sess.Summary != "résumé" || sess.SummaryCovers != 2 {
+		t.Errorf("résumé %q sur %d messages, attendu conservé", sess.Summary, sess.SummaryCovers)
+	}
+
+	// Une troncature dans la partie résumée invalide le résumé.
+	if err := s.Truncate(id, 1); err != nil {
+		t.Fatal(err)
+	}
+	sess, _ = s.Session(id)
+	if sess.Messages != 1 || sess.Summary != "" || sess.SummaryCovers != 0 {
+		t.Errorf("après troncature : %+v", sess)
+	}
+}
+
+func TestStoreAlreadyOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tars.db")
+	s := openTestStore(t, path)
+	defer s.Close()
+	if _, err := Open(path); err == nil || !strings.Contains(err.Error(), "déjà ouvert") {
+		t.Errorf("seconde ouverture : %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"tars/config"
+	"tars/history"
+)
+
+const historyUsage = "usage : tars history list | show [SESSION|last] | delete SESSION"
+
+// runHistory implémente `tars history list|show|delete` sur la base config.HistoryPath.
+func runHistory(args []string) error {
+	config.LoadEnv()
+	if config.HistoryPath == "" {
+		return errors.New("historique désactivé (HistoryPath vide)")
+	}
+	if len(args) == 0 {
+		return errors.New(historyUsage)
+	}
+	store, err := history.Open(config.HistoryPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	switch args[0] {
+	case "list":
+		sessions, err := store.Sessions()
+		if err != nil {
+			return err
+		}
+		if len(sessions) == 0 {
+			fmt.Println("Aucune conversation enregistrée.")
+			return nil
+		}
+		return history.PrintSessions(os.Stdout, sessions)
+
+	case "show":
+		id := "last"
+		if len(args) > 1 {
+			id = args[1]
+		}
+		if id == "last" {
+			last, ok, err := store.LastSession()
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return errors.New("aucune conversation enregistrée")
+			}
+			id = last.ID
+		}
+		entries, err := store.Entries(id)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Session %s, %d message(s)\n", id, len(entries))
+		return history.PrintEntries(os.Stdout, entries)
+
+	case "delete":
+		if len(args) < 2 {
+			return errors.New(historyUsage)
+		}
+		for _, id := range args[1:] {
+			if err := store.Delete(id); err != nil {
+				return err
+			}
+			fmt.Printf("Session %s supprimée.\n", id)
+		}
+		return nil
+
+	default:
+		return errors.New(historyUsage)
+	}
+}
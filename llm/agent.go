@@ -0,0 +1,107 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"tars/config"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// ToolRound est un aller-retour de function calling : le message du modèle portant les
+// ToolCalls et les messages "tool" contenant les résultats, à ajouter tels quels à l'historique.
+type ToolRound struct {
+	Assistant openai.ChatCompletionMessage
+	Results   []openai.ChatCompletionMessage
+}
+
+// AgentEvent est émis par RunAgent dans l'ordre de la conversation :
+// soit un morceau de texte à prononcer, soit un aller-retour d'outils terminé.
+type AgentEvent struct {
+	Text      string
+	ToolRound *ToolRound
+}
+
+// RunAgent interroge le modèle avec les outils disponibles, exécute les ToolCalls retournés
+// via l'ActionRouter, lui renvoie les résultats et recommence jusqu'à obtenir une réponse
+// texte (au plus config.MaxToolIterations allers-retours, puis une dernière requête sans outils ;
+// si le modèle y répond encore par des ToolCalls, RunAgent s'arrête sur une erreur).
+// En streaming, le texte est émis phrase par phrase ; sinon en une fois.
+// events est fermé à la fin ; la réponse finale (ou l'erreur) est retournée, ce qui la rattache
+// sans ambiguïté à la requête de l'appelant.
+func (lp *LLMProcessor) RunAgent(ctx context.Context, messages []openai.ChatCompletionMessage, stream bool, events chan<- AgentEvent) LLMResponse {
+	defer close(events)
+
+	emit := func(ev AgentEvent) bool {
+		select {
+		case events <- ev:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	// Copie pour ne pas modifier le tableau de l'appelant en ajoutant les messages d'outils.
+	msgs := append([]openai.ChatCompletionMessage(nil), messages...)
+
+	for iteration := 0; ; iteration++ {
+		availableTools := lp.tools
+		forced := iteration >= config.MaxToolIterations
+		if forced {
+			log.Printf("LLM Agent: Limite de %d allers-retours d'outils atteinte, réponse forcée sans outils.", config.MaxToolIterations)
+			availableTools = nil
+		}
+
+		var msg openai.ChatCompletionMessage
+		var err error
+		if stream {
+			msg, err = lp.streamCompletion(ctx, msgs, availableTools, func(chunk string) bool {
+				return emit(AgentEvent{Text: chunk})
+			})
+		} else {
+			msg, err = lp.complete(ctx, msgs, availableTools)
+			if err == nil && msg.Content != "" && !emit(AgentEvent{Text: msg.Content}) {
+				err = ctx.Err()
+			}
+		}
+		if err != nil {
+			return LLMResponse{Error: err}
+		}
+
+		if len(msg.ToolCalls) == 0 || lp.router == nil {
+			if msg.Content == "" {
+				log.Println("LLM Agent: Réponse sans contenu.")
+			}
+			return LLMResponse{Content: msg.Content}
+		}
+		if forced {
+			// Certains serveurs ignorent l'absence d'outils : on n'exécute pas ces appels.
+			if msg.Content != "" {
+				log.Printf("LLM Agent: %d tool call(s) ignoré(s) après la limite, réponse texte conservée.", len(msg.ToolCalls))
+				return LLMResponse{Content: msg.Content}
+			}
+			return LLMResponse{Error: fmt.Errorf("le modèle demande encore des outils après %d allers-retours", config.MaxToolIterations)}
+		}
+
+		log.Printf("LLM Agent: %d tool call(s) reçu(s) (itération %d).", len(msg.ToolCalls), iteration+1)
+		round := &ToolRound{Assistant: msg}
+		for _, res := range lp.router.ProcessToolCalls(ctx, msg.ToolCalls) {
+			round.Results = append(round.Results, openai.ChatCompletionMessage{
+				Role:       openai.ChatMessageRoleTool,
+				Content:    res.Content,
+				ToolCallID: res.ToolCallID,
+			})
+		}
+		if ctx.Err() != nil {
+			return LLMResponse{Error: ctx.Err()}
+		}
+		if !emit(AgentEvent{ToolRound: round}) {
+			return LLMResponse{Error: ctx.Err()}
+		}
+
+		msgs = append(msgs, round.Assistant)
+		msgs = append(msgs, round.Results...)
+	}
+}
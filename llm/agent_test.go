@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tars/actions"
+	"tars/config"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// stubborn est un LLM factice qui répond toujours par un tool call, même sans outils proposés.
+type stubborn struct {
+	content string // Texte accompagnant le tool call
+	calls   int
+}
+
+func (s *stubborn) Name() string { return "test" }
+
+func (s *stubborn) Chat(_ context.Context, _ Request) (openai.ChatCompletionMessage, error) {
+	s.calls++
+	return openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleAssistant,
+		Content: s.content,
+		ToolCalls: []openai.ToolCall{
+			{ID: "call", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "ping", Arguments: "{}"}},
+		},
+	}, nil
+}
+
+func (s *stubborn) ChatStream(ctx context.Context, req Request, _ func(string) bool) (openai.ChatCompletionMessage, error) {
+	return s.Chat(ctx, req)
+}
+
+// runStubborn fait tourner RunAgent contre model et retourne la réponse finale et le nombre d'allers-retours.
+func runStubborn(t *testing.T, model *stubborn) (LLMResponse, int) {
+	t.Helper()
+	registry := actions.NewRegistry()
+	ping := actions.NewExecutor("ping", "Répond pong.", func(context.Context, struct{}) (any, error) {
+		return "pong", nil
+	})
+	if err := registry.Register(ping); err != nil {
+		t.Fatal(err)
+	}
+	lp := NewLLMProcessor(model, actions.NewActionRouter(registry), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events := make(chan AgentEvent)
+	out := make(chan LLMResponse, 1)
+	go func() {
+		out <- lp.RunAgent(ctx, []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "ping"}}, false, events)
+	}()
+	rounds := 0
+	for ev := range events {
+		if ev.ToolRound != nil {
+			rounds++
+		}
+	}
+	select {
+	case resp := <-out:
+		return resp, rounds
+	case <-ctx.Done():
+		t.Fatal("pas de réponse finale")
+		return LLMResponse{}, rounds
+	}
+}
+
+func TestRunAgentStopsAfterForcedRequest(t *testing.T) {
+	model := &stubborn{}
+	resp, rounds := runStubborn(t, model)
+	if resp.Error == nil {
+		t.Errorf("réponse %q, attendu une erreur", resp.Content)
+	}
+	if rounds != config.MaxToolIterations {
+		t.Errorf("%d allers-retours, attendu %d", rounds, config.MaxToolIterations)
+	}
+	if model.calls != config.MaxToolIterations+1 {
+		t.Errorf("%d requêtes, attendu %d", model.calls, config.MaxToolIterations+1)
+	}
+}
+
+func TestRunAgentKeepsForcedContent(t *testing.T) {
+	model := &stubborn{content: "pong"}
+	resp, _ := runStubborn(t, model)
+	if resp.Error != nil || resp.Content != "pong" {
+		t.Errorf("réponse %q (erreur %v), attendu %q", resp.Content, resp.Error, "pong")
+	}
+}
@@ -0,0 +1,110 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// requestBody fait une requête au backend créé par newLLM contre un serveur factice et
+// retourne le corps JSON reçu par le serveur.
+func requestBody(t *testing.T, reply string, newLLM func(url string) LLM) map[string]any {
+	t.Helper()
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("requête invalide : %s", data)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, reply)
+	}))
+	defer srv.Close()
+
+	msgs := []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Bonjour"}}
+	if _, err := newLLM(srv.URL).Chat(context.Background(), Request{Messages: msgs}); err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func temperature(t float32) *float32 { return &t }
+
+func TestOllamaTemperature(t *testing.T) {
+	const reply = `{"message":{"role":"assistant","content":"Salut"},"done":true}`
+	for _, tt := range []struct {
+		name        string
+		temperature *float32
+		want        any // nil : option absente
+	}{
+		{"par défaut", nil, nil},
+		{"zéro", temperature(0), 0.0},
+		{"0.7", temperature(0.7), 0.7},
+	} {
+		body := requestBody(t, reply, func(url string) LLM {
+			return NewOllamaChat(url, Options{Model: "llama3.1", Temperature: tt.temperature})
+		})
+		options, _ := body["options"].(map[string]any)
+		if got := options["temperature"]; got != tt.want {
+			t.Errorf("%s : temperature %v, attendu %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOpenAITemperature(t *testing.T) {
+	const reply = `{"choices":[{"message":{"role":"assistant","content":"Salut"}}]}`
+	for _, tt := range []struct {
+		name        string
+		temperature *float32
+		want        func(v any) bool
+	}{
+		{"par défaut", nil, func(v any) bool { return v == nil }},
+		{"zéro", temperature(0), func(v any) bool { f, ok := v.(float64); return ok && f > 0 && f < 1e-30 }},
+		{"0.7", temperature(0.7), func(v any) bool { f, ok := v.(float64); return ok && math.Abs(f-0.7) < 1e-6 }},
+	} {
+		body := requestBody(t, reply, func(url string) LLM {
+			cfg := openai.DefaultConfig("test")
+			cfg.BaseURL = url
+			return NewOpenAIChat(openai.NewClientWithConfig(cfg), "test", Options{Model: "gpt-4o", Temperature: tt.temperature})
+		})
+		if !tt.want(body["temperature"]) {
+			t.Errorf("%s : temperature %v", tt.name, body["temperature"])
+		}
+	}
+}
+
+func TestOllamaToolCallIDsUnique(t *testing.T) {
+	// Ollama ne donne pas d'ID : ceux générés ne doivent pas se répéter d'une réponse à l'autre.
+	const reply = `{"message":{"role":"assistant","content":"","tool_calls":[` +
+		`{"function":{"name":"heure","arguments":{}}},{"function":{"name":"meteo","arguments":{}}}]},"done":true}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, reply)
+	}))
+	defer srv.Close()
+
+	model := NewOllamaChat(srv.URL, Options{Model: "llama3.1"})
+	msgs := []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Bonjour"}}
+	seen := map[string]bool{}
+	for range 3 {
+		msg, err := model.Chat(context.Background(), Request{Messages: msgs})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(msg.ToolCalls) != 2 {
+			t.Fatalf("%d tool calls, attendu 2", len(msg.ToolCalls))
+		}
+		for _, call := range msg.ToolCalls {
+			if call.ID == "" || seen[call.ID] {
+				t.Errorf("ID de tool call %q vide ou en double", call.ID)
+			}
+			seen[call.ID] = true
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package llm
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SentenceChunker découpe le texte streamé par le LLM en morceaux prononçables
+// (phrases, ou propositions pour les phrases trop longues) à envoyer au TTS dès qu'ils sont complets.
+// Il gère les abréviations (« M. », « Dr. », « p. ex. »), les initiales, les décimales
+// (« 3.5 », « 3,5 ») et la typographie française (espaces insécables avant « ! ? ; : »,
+// guillemets « »).
+type SentenceChunker struct {
+	MinChars int // En dessous, le morceau est fusionné avec la phrase suivante
+	MaxChars int // Au-delà, on coupe sur une ponctuation de proposition (, ; : —)
+
+	buf []rune
+}
+
+func NewSentenceChunker() *SentenceChunker {
+	return &SentenceChunker{MinChars: 20, MaxChars: 200}
+}
+
+// Abréviations courantes (en minuscules, sans le point final) qui ne terminent pas une phrase.
+var abbreviations = map[string]bool{
+	"m": true, "mm": true, "mme": true, "mmes": true, "mlle": true, "mlles": true,
+	"dr": true, "pr": true, "me": true, "st": true, "ste": true, "mgr": true,
+	"cf": true, "ex": true, "p": true, "pp": true, "av": true, "apr": true, "env": true,
+	"vol": true, "chap": true, "fig": true, "no": true, "tél": true, "tel": true,
+	"mr": true, "mrs": true, "ms": true, "jr": true, "sr": true, "vs": true,
+	"e.g": true, "i.e": true, "p.ex": true, "approx": true, "min": true, "max": true,
+}
+
+const (
+	nbsp       = '\u00a0' // espace insécable
+	narrowNbsp = '\u202f' // espace fine insécable
+)
+
+// isSpace inclut les espaces insécables (unicode.IsSpace les reconnaît déjà).
+func isSpace(r rune) bool {
+	return unicode.IsSpace(r)
+}
+
+// isBreakingSpace exclut les espaces insécables, où l'on ne coupe jamais.
+func isBreakingSpace(r rune) bool {
+	return unicode.IsSpace(r) && r != nbsp && r != narrowNbsp
+}
+
+func isSentenceEnd(r rune) bool {
+	return r == '.' || r == '!' || r == '?' || r == '…'
+}
+
+func isCloser(r rune) bool {
+	return r == '»' || r == '"' || r == '”' || r == '’' || r == ')' || r == ']' || r == '\''
+}
+
+func isClauseEnd(r rune) bool {
+	return r == ',' || r == ';' || r == ':' || r == '—' || r == '–'
+}
+
+// Push ajoute un delta et retourne les morceaux devenus complets.
+func (c *SentenceChunker) Push(delta string) []string {
+	c.buf = append(c.buf, []rune(delta)...)
+
+	var out []string
+	for {
+		end := c.nextChunkEnd()
+		if end < 0 {
+			break
+		}
+		if chunk := strings.TrimSpace(string(c.buf[:end])); chunk != "" {
+			out = append(out, chunk)
+		}
+		c.buf = c.buf[end:]
+	}
+	return out
+}
+
+// Flush retourne le texte restant (fin du stream).
+func (c *SentenceChunker) Flush() string {
+	rest := strings.TrimSpace(string(c.buf))
+	c.buf = c.buf[:0]
+	return rest
+}
+
+// nextChunkEnd retourne la fin (exclusive) du prochain morceau à émettre, ou -1.
+func (c *SentenceChunker) nextChunkEnd() int {
+	from := 0
+	for {
+		end := sentenceBoundary(c.buf, from)
+		if end < 0 {
+			break
+		}
+		if runeLen(c.buf[:end]) >= c.MinChars {
+			return end
+		}
+		from = end // Trop court : on le fusionne avec la phrase suivante
+	}
+
+	if len(c.buf) > c.MaxChars {
+		return clauseBoundary(c.buf, c.MaxChars)
+	}
+	return -1
+}
+
+// runeLen compte les caractères hors espaces de bord.
+func runeLen(r []rune) int {
+	start, end := 0, len(r)
+	for start < end && isSpace(r[start]) {
+		start++
+	}
+	for end > start && isSpace(r[end-1]) {
+		end--
+	}
+	return end - start
+}
+
+// sentenceBoundary cherche une fin de phrase à partir de from. Une ponctuation n'est une fin
+// de phrase que si elle est suivie d'un espace puis d'un caractère qui ne la contredit pas ;
+// tant que ce caractère n'est pas arrivé dans le stream, la décision est reportée.
+func sentenceBoundary(buf []rune, from int) int {
+	for i := from; i < len(buf); i++ {
+		r := buf[i]
+		if r == '\n' {
+			if runeLen(buf[:i]) > 0 {
+				return i + 1
+			}
+			continue
+		}
+		if !isSentenceEnd(r) {
+			continue
+		}
+
+		// Ponctuation multiple (« ?! », « ... ») puis guillemets/parenthèses fermants,
+		// éventuellement précédés d'une espace insécable (« Bonjour. » en typographie française).
+		j := i + 1
+		for j < len(buf) && isSentenceEnd(buf[j]) {
+			j++
+		}
+		for {
+			k := j
+			for k < len(buf) && (buf[k] == nbsp || buf[k] == narrowNbsp) {
+				k++
+			}
+			if k < len(buf) && isCloser(buf[k]) {
+				j = k + 1
+				continue
+			}
+			break
+		}
+
+		if j >= len(buf) {
+			return -1 // Il faut voir la suite
+		}
+		if !isSpace(buf[j]) {
+			// « 3.5 », « example.com », « ?» collé... : pas une fin de phrase.
+			i = j - 1
+			continue
+		}
+		next := j
+		for next < len(buf) && isSpace(buf[next]) {
+			next++
+		}
+		if next >= len(buf) {
+			return -1
+		}
+		if buf[next] == '»' {
+			// Guillemet fermant séparé par une espace ordinaire : il appartient à la phrase.
+			i = next
+			for i+1 < len(buf) && isSpace(buf[i+1]) {
+				i++
+			}
+			if i+1 >= len(buf) {
+				return -1
+			}
+			return i + 1
+		}
+
+		if r == '.' && j == i+1 {
+			if isAbbreviation(buf[:i]) || unicode.IsLower(buf[next]) {
+				i = j - 1
+				continue
+			}
+		}
+		return j
+	}
+	return -1
+}
+
+// isAbbreviation indique si le mot qui précède le point est une abréviation ou une initiale.
+func isAbbreviation(before []rune) bool {
+	start := len(before)
+	for start > 0 && !isSpace(before[start-1]) && before[start-1] != '(' && before[start-1] != '«' {
+		start--
+	}
+	word := before[start:]
+	if len(word) == 0 {
+		return false
+	}
+	if len(word) == 1 && unicode.IsUpper(word[0]) {
+		return true // Initiale : « J. R. R. Tolkien »
+	}
+	return abbreviations[strings.ToLower(string(word))]
+}
+
+// clauseBoundary coupe une phrase trop longue sur la dernière ponctuation de proposition
+// suivie d'un espace avant limit, ou à défaut sur le dernier espace.
+func clauseBoundary(buf []rune, limit int) int {
+	lastSpace := -1
+	for i := min(limit, len(buf)-1); i > 0; i-- {
+		if !isBreakingSpace(buf[i]) {
+			continue
+		}
+		if isClauseEnd(buf[i-1]) {
+			return i
+		}
+		if lastSpace < 0 {
+			lastSpace = i
+		}
+	}
+	return lastSpace
+}
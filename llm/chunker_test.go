@@ -0,0 +1,162 @@
+package llm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// chunkAll fait passer deltas dans un SentenceChunker et retourne tous les morceaux, Flush compris.
+func chunkAll(c *SentenceChunker, deltas []string) []string {
+	var out []string
+	for _, d := range deltas {
+		out = append(out, c.Push(d)...)
+	}
+	if rest := c.Flush(); rest != "" {
+		out = append(out, rest)
+	}
+	return out
+}
+
+// runes découpe s en deltas d'un caractère, le pire cas du streaming.
+func runes(s string) []string {
+	var out []string
+	for _, r := range s {
+		out = append(out, string(r))
+	}
+	return out
+}
+
+func TestSentenceChunker(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		deltas   []string // Découpage supplémentaire à tester, en plus de caractère par caractère et d'un bloc
+		minChars int      // 0 : valeur de NewSentenceChunker
+		maxChars int
+		want     []string
+	}{
+		{
+			name:  "phrases",
+			input: "Bonjour, je suis TARS. Que puis-je faire pour vous aujourd'hui ? Je suis prêt !",
+			want:  []string{"Bonjour, je suis TARS.", "Que puis-je faire pour vous aujourd'hui ?", "Je suis prêt !"},
+		},
+		{
+			name:  "abréviations",
+			input: "M. Dupont habite rue du Dr. Roux. Il y va p. ex. le dimanche matin.",
+			want:  []string{"M. Dupont habite rue du Dr. Roux.", "Il y va p. ex. le dimanche matin."},
+		},
+		{
+			name:  "initiales",
+			input: "J. R. R. Tolkien a écrit ce livre. C'est un classique de la fantasy.",
+			want:  []string{"J. R. R. Tolkien a écrit ce livre.", "C'est un classique de la fantasy."},
+		},
+		{
+			name:  "minuscule après le point",
+			input: "Il est environ 15 h. env. selon la radio. Ensuite il pleuvra sur la ville.",
+			want:  []string{"Il est environ 15 h. env. selon la radio.", "Ensuite il pleuvra sur la ville."},
+		},
+		{
+			name:   "décimales",
+			input:  "La version 3.5 est sortie hier soir. Elle pèse 3,5 Go sur le disque dur.",
+			deltas: []string{"La version 3.", "5 est sortie hier soir.", " Elle pèse 3,", "5 Go sur le disque dur."},
+			want:   []string{"La version 3.5 est sortie hier soir.", "Elle pèse 3,5 Go sur le disque dur."},
+		},
+		{
+			name:  "guillemets et espaces insécables",
+			input: "Il m'a dit\u00a0: «\u00a0Bonjour tout le monde.\u00a0» Puis il est parti sans rien ajouter.",
+			want:  []string{"Il m'a dit\u00a0: «\u00a0Bonjour tout le monde.\u00a0»", "Puis il est parti sans rien ajouter."},
+		},
+		{
+			name:  "guillemet après une espace ordinaire",
+			input: "Il a répondu « Je ne sais pas encore. » Puis il a haussé les épaules.",
+			want:  []string{"Il a répondu « Je ne sais pas encore. »", "Puis il a haussé les épaules."},
+		},
+		{
+			name:  "espaces fines insécables",
+			input: "Vraiment\u202f? Oui, c'est exactement ce que je pense\u202f! Alors allons-y tout de suite.",
+			want:  []string{"Vraiment\u202f? Oui, c'est exactement ce que je pense\u202f!", "Alors allons-y tout de suite."},
+		},
+		{
+			name:  "fusion des phrases courtes",
+			input: "Oui. Non. Bof. Je ne sais vraiment pas quoi répondre. Bon.",
+			want:  []string{"Oui. Non. Bof. Je ne sais vraiment pas quoi répondre.", "Bon."}, // Le reste est rendu par Flush
+		},
+		{
+			name:     "découpe des phrases trop longues",
+			input:    "Nous irons au marché demain matin, puis nous passerons chez le boulanger; enfin nous rentrerons tranquillement à la maison par le parc.",
+			minChars: 5,
+			maxChars: 40,
+			want: []string{
+				"Nous irons au marché demain matin,",
+				"puis nous passerons chez le boulanger;",
+				"enfin nous rentrerons tranquillement à",
+				"la maison par le parc.",
+			},
+		},
+		{
+			name:  "retour à la ligne",
+			input: "Voici la liste des courses\ndu pain, du lait et des œufs frais.",
+			want:  []string{"Voici la liste des courses", "du pain, du lait et des œufs frais."},
+		},
+		{
+			name:  "ponctuation multiple",
+			input: "Tu es vraiment sûr de toi ?! Je n'en reviens pas... Enfin, passons à autre chose.",
+			want:  []string{"Tu es vraiment sûr de toi ?!", "Je n'en reviens pas...", "Enfin, passons à autre chose."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modes := map[string][]string{
+				"caractère par caractère": runes(tt.input),
+				"en un bloc":              {tt.input},
+			}
+			if tt.deltas != nil {
+				if strings.Join(tt.deltas, "") != tt.input {
+					t.Fatal("deltas ne recompose pas input")
+				}
+				modes["deltas"] = tt.deltas
+			}
+			for mode, deltas := range modes {
+				c := NewSentenceChunker()
+				if tt.minChars > 0 {
+					c.MinChars, c.MaxChars = tt.minChars, tt.maxChars
+				}
+				if got := chunkAll(c, deltas); !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("%s :\n got %q\nwant %q", mode, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// TestSentenceChunkerDefersDecision vérifie qu'un delta qui s'arrête juste après une ponctuation
+// ne produit rien tant que la suite ne permet pas de trancher.
+func TestSentenceChunkerDefersDecision(t *testing.T) {
+	steps := []struct {
+		delta string
+		want  []string
+	}{
+		{"Le rendez-vous est fixé à 3.", nil}, // Décimale ou fin de phrase ?
+		{"5", nil},                            // Décimale
+		{" heures avec le Dr.", nil},          // Abréviation ou fin de phrase ?
+		{" ", nil},                            // Toujours pas tranché
+		{"Martin.", nil},                      // Abréviation
+		{" ", nil},                            // Le prochain caractère décide
+		{"Il", []string{"Le rendez-vous est fixé à 3.5 heures avec le Dr. Martin."}},
+		{" sera à l'heure.", nil},
+	}
+	c := NewSentenceChunker()
+	for _, step := range steps {
+		if got := c.Push(step.delta); !reflect.DeepEqual(got, step.want) {
+			t.Fatalf("Push(%q) = %q, attendu %q", step.delta, got, step.want)
+		}
+	}
+	if rest := c.Flush(); rest != "Il sera à l'heure." {
+		t.Errorf("Flush() = %q", rest)
+	}
+	if rest := c.Flush(); rest != "" {
+		t.Errorf("second Flush() = %q, attendu vide", rest)
+	}
+}
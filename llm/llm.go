@@ -0,0 +1,107 @@
+package llm
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"strings"
+
+	"tars/config"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Les messages, outils et tool calls gardent les types go-openai dans tout le pipeline ;
+// chaque backend les convertit vers son propre format si besoin.
+
+// Request est une requête de chat vers un modèle.
+type Request struct {
+	Messages []openai.ChatCompletionMessage
+	Tools    []openai.Tool // Vide : pas de function calling pour cette requête
+}
+
+// LLM est implémenté par chaque backend de modèle de langage.
+type LLM interface {
+	// Name identifie le backend et le modèle dans les logs.
+	Name() string
+	// Chat retourne le message complet du modèle (texte et/ou tool calls).
+	Chat(ctx context.Context, req Request) (openai.ChatCompletionMessage, error)
+	// ChatStream passe chaque fragment de texte à onContent dès sa réception (onContent retourne
+	// false pour abandonner) et retourne le message complet, tool calls réassemblés.
+	ChatStream(ctx context.Context, req Request, onContent func(delta string) bool) (openai.ChatCompletionMessage, error)
+}
+
+// Options communes aux backends, lues depuis la config.
+type Options struct {
+	Model       string
+	Temperature *float32 // nil : valeur par défaut du backend
+	MaxTokens   int      // 0 : pas de limite
+}
+
+// Providers acceptés pour config.LLMProvider.
+const (
+	ProviderOpenAI           = "openai"
+	ProviderOpenAICompatible = "openai-compatible" // Tout serveur exposant /v1/chat/completions (llama.cpp, LM Studio, vLLM, Ollama /v1...)
+	ProviderOllama           = "ollama"            // API native d'Ollama (/api/chat)
+)
+
+// New crée le backend choisi par config.LLMProvider. client n'est utilisé que par le provider "openai".
+func New(client *openai.Client) (LLM, error) {
+	opts := Options{
+		Model:     config.LLMModel,
+		MaxTokens: config.LLMMaxTokens,
+	}
+	if t := config.LLMTemperature; t >= 0 {
+		opts.Temperature = &t
+	}
+
+	switch config.Provider(config.LLMProvider) {
+	case ProviderOpenAI:
+		if client == nil {
+			return nil, fmt.Errorf("LLM OpenAI: client OpenAI manquant")
+		}
+		if opts.Model == "" {
+			opts.Model = openai.GPT3Dot5Turbo
+		}
+		return NewOpenAIChat(client, "openai", opts), nil
+	case ProviderOpenAICompatible:
+		if config.LLMBaseURL == "" {
+			return nil, fmt.Errorf("LLM %s: LLMBaseURL doit être défini", ProviderOpenAICompatible)
+		}
+		if opts.Model == "" {
+			return nil, fmt.Errorf("LLM %s: LLMModel doit être défini", ProviderOpenAICompatible)
+		}
+		cfg := openai.DefaultConfig(config.LLMAPIKey)
+		cfg.BaseURL = strings.TrimRight(config.LLMBaseURL, "/")
+		return NewOpenAIChat(openai.NewClientWithConfig(cfg), cfg.BaseURL, opts), nil
+	case ProviderOllama:
+		if opts.Model == "" {
+			return nil, fmt.Errorf("LLM %s: LLMModel doit être défini (ex. llama3.1)", ProviderOllama)
+		}
+		return NewOllamaChat(config.OllamaURL, opts), nil
+	default:
+		return nil, fmt.Errorf("LLM: provider inconnu %q (attendu %q, %q ou %q)",
+			config.LLMProvider, ProviderOpenAI, ProviderOpenAICompatible, ProviderOllama)
+	}
+}
+
+// ensureToolCallIDs donne un ID aux tool calls qui n'en ont pas (Ollama, certains serveurs
+// compatibles) : les messages "tool" renvoyés au modèle s'y rattachent par tool_call_id.
+func ensureToolCallIDs(calls []openai.ToolCall) []openai.ToolCall {
+	for i := range calls {
+		if calls[i].ID == "" {
+			calls[i].ID = newToolCallID()
+		}
+		if calls[i].Type == "" {
+			calls[i].Type = openai.ToolTypeFunction
+		}
+	}
+	return calls
+}
+
+// newToolCallID génère un ID de tool call aléatoire. Un compteur repartirait de zéro à chaque
+// réponse : l'historique contiendrait des tool_call_id en double d'un aller-retour à l'autre,
+// ce que les serveurs compatibles OpenAI refusent et qui casse l'appariement des résultats.
+func newToolCallID() string {
+	return "call_" + rand.Text()[:16]
+}
@@ -0,0 +1,213 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// OllamaChat parle à l'API native d'Ollama (POST /api/chat), en streaming NDJSON ou non.
+type OllamaChat struct {
+	baseURL    string
+	opts       Options
+	httpClient *http.Client
+}
+
+// NewOllamaChat crée le backend. baseURL est l'adresse du serveur (ex. http://127.0.0.1:11434).
+func NewOllamaChat(baseURL string, opts Options) *OllamaChat {
+	return &OllamaChat{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		opts:       opts,
+		httpClient: &http.Client{}, // Pas de timeout global : le premier chargement du modèle peut être long, ctx fait foi
+	}
+}
+
+func (c *OllamaChat) Name() string { return "ollama/" + c.opts.Model }
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"` // Objet JSON, pas une chaîne comme chez OpenAI
+	} `json:"function"`
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Tools    []openai.Tool   `json:"tools,omitempty"` // Même format que l'API OpenAI
+	Stream   bool            `json:"stream"`
+	Options  map[string]any  `json:"options,omitempty"`
+}
+
+type ollamaResponse struct {
+	Message ollamaMessage `json:"message"`
+	Done    bool          `json:"done"`
+	Error   string        `json:"error"`
+}
+
+// toOllamaMessages convertit l'historique au format Ollama. Les messages "tool" n'ont pas
+// de tool_call_id chez Ollama : on y reporte le nom de la fonction appelée.
+func toOllamaMessages(msgs []openai.ChatCompletionMessage) []ollamaMessage {
+	toolNames := make(map[string]string)
+	out := make([]ollamaMessage, 0, len(msgs))
+	for _, m := range msgs {
+		om := ollamaMessage{Role: m.Role, Content: m.Content}
+		for _, tc := range m.ToolCalls {
+			toolNames[tc.ID] = tc.Function.Name
+			var call ollamaToolCall
+			call.Function.Name = tc.Function.Name
+			call.Function.Arguments = json.RawMessage(tc.Function.Arguments)
+			if !json.Valid(call.Function.Arguments) {
+				call.Function.Arguments = json.RawMessage("{}")
+			}
+			om.ToolCalls = append(om.ToolCalls, call)
+		}
+		if m.Role == openai.ChatMessageRoleTool {
+			om.ToolName = toolNames[m.ToolCallID]
+		}
+		out = append(out, om)
+	}
+	return out
+}
+
+// fromOllamaToolCalls convertit les tool calls d'Ollama (sans ID) au format OpenAI.
+func fromOllamaToolCalls(calls []ollamaToolCall) []openai.ToolCall {
+	out := make([]openai.ToolCall, 0, len(calls))
+	for _, tc := range calls {
+		args := string(tc.Function.Arguments)
+		if args == "" || args == "null" {
+			args = "{}"
+		}
+		out = append(out, openai.ToolCall{
+			ID:   newToolCallID(),
+			Type: openai.ToolTypeFunction,
+			Function: openai.FunctionCall{
+				Name:      tc.Function.Name,
+				Arguments: args,
+			},
+		})
+	}
+	return out
+}
+
+func (c *OllamaChat) post(ctx context.Context, req Request, stream bool) (*http.Response, error) {
+	body := ollamaRequest{
+		Model:    c.opts.Model,
+		Messages: toOllamaMessages(req.Messages),
+		Tools:    req.Tools,
+		Stream:   stream,
+		Options:  map[string]any{},
+	}
+	if c.opts.Temperature != nil {
+		body.Options["temperature"] = *c.opts.Temperature
+	}
+	if c.opts.MaxTokens > 0 {
+		body.Options["num_predict"] = c.opts.MaxTokens
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/chat", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("requête Ollama (%s): %w", c.baseURL, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		var apiErr ollamaResponse
+		if json.Unmarshal(msg, &apiErr) == nil && apiErr.Error != "" {
+			return nil, fmt.Errorf("Ollama: HTTP %d: %s", resp.StatusCode, apiErr.Error)
+		}
+		return nil, fmt.Errorf("Ollama: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+func (c *OllamaChat) Chat(ctx context.Context, req Request) (openai.ChatCompletionMessage, error) {
+	resp, err := c.post(ctx, req, false)
+	if err != nil {
+		return openai.ChatCompletionMessage{}, err
+	}
+	defer resp.Body.Close()
+
+	var out ollamaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return openai.ChatCompletionMessage{}, fmt.Errorf("décodage réponse Ollama: %w", err)
+	}
+	if out.Error != "" {
+		return openai.ChatCompletionMessage{}, fmt.Errorf("Ollama: %s", out.Error)
+	}
+	return openai.ChatCompletionMessage{
+		Role:      openai.ChatMessageRoleAssistant,
+		Content:   out.Message.Content,
+		ToolCalls: fromOllamaToolCalls(out.Message.ToolCalls),
+	}, nil
+}
+
+func (c *OllamaChat) ChatStream(ctx context.Context, req Request, onContent func(delta string) bool) (openai.ChatCompletionMessage, error) {
+	msg := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant}
+
+	resp, err := c.post(ctx, req, true)
+	if err != nil {
+		return msg, err
+	}
+	defer resp.Body.Close()
+
+	var fullResponse strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chunk ollamaResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return msg, fmt.Errorf("décodage stream Ollama: %w", err)
+		}
+		if chunk.Error != "" {
+			return msg, fmt.Errorf("Ollama: %s", chunk.Error)
+		}
+		// Les tool calls arrivent complets, dans un ou plusieurs morceaux.
+		msg.ToolCalls = append(msg.ToolCalls, fromOllamaToolCalls(chunk.Message.ToolCalls)...)
+
+		if chunk.Message.Content != "" {
+			fullResponse.WriteString(chunk.Message.Content)
+			if !onContent(chunk.Message.Content) {
+				msg.Content = fullResponse.String()
+				return msg, context.Canceled
+			}
+		}
+		if chunk.Done {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return msg, fmt.Errorf("réception stream Ollama: %w", err)
+	}
+
+	msg.Content = fullResponse.String()
+	return msg, nil
+}
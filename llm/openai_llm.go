@@ -5,117 +5,136 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"math"
+	"strings"
 
 	"github.com/sashabaranov/go-openai"
 )
 
-// Définition des outils (si vous voulez les utiliser via l'API function calling plus tard)
-// Pour l'instant, on ne les utilise pas dans GetResponse pour garder le flux simple.
-var tools = []openai.Tool{
-	// {
-	//  Type: openai.ToolTypeFunction,
-	//  Function: &openai.FunctionDefinition{
-	//      Name:        "getCurrentWeather",
-	//      Description: "Get the current weather in a given location",
-	//      Parameters: map[string]interface{}{
-	//          "type": "object",
-	//          "properties": map[string]interface{}{
-	//              "location": map[string]interface{}{
-	//                  "type":        "string",
-	//                  "description": "The city and state, e.g. San Francisco, CA",
-	//              },
-	//              "unit": map[string]interface{}{
-	//                  "type": "string",
-	//                  "enum": []string{"celsius", "fahrenheit"},
-	//              },
-	//          },
-	//          "required": []string{"location"},
-	//      },
-	//  },
-	// },
+// OpenAIChat parle à l'API Chat Completions d'OpenAI ou de tout serveur compatible
+// (le client go-openai est alors créé avec un BaseURL personnalisé).
+type OpenAIChat struct {
+	client *openai.Client
+	name   string
+	opts   Options
 }
 
-type LLMResponse struct {
-	Content   string
-	ToolCalls []openai.ToolCall
-	Error     error
+// NewOpenAIChat crée le backend. name sert uniquement aux logs (ex. "openai" ou l'URL du serveur).
+func NewOpenAIChat(client *openai.Client, name string, opts Options) *OpenAIChat {
+	return &OpenAIChat{client: client, name: name, opts: opts}
 }
 
-type LLMProcessor struct {
-	client     *openai.Client
-	outputChan chan LLMResponse
-}
+func (c *OpenAIChat) Name() string { return c.name + "/" + c.opts.Model }
 
-func NewLLMProcessor(client *openai.Client, outputChan chan LLMResponse) *LLMProcessor {
-	return &LLMProcessor{
-		client:     client,
-		outputChan: outputChan,
+func (c *OpenAIChat) request(req Request, stream bool) openai.ChatCompletionRequest {
+	r := openai.ChatCompletionRequest{
+		Model:       c.opts.Model,
+		Messages:    req.Messages,
+		Tools:       req.Tools,
+		Temperature: c.temperature(),
+		MaxTokens:   c.opts.MaxTokens,
+		Stream:      stream,
+	}
+	if len(req.Tools) > 0 {
+		r.ParallelToolCalls = true
 	}
+	return r
 }
 
-func (lp *LLMProcessor) GetResponse(ctx context.Context, messages []openai.ChatCompletionMessage, availableTools []openai.Tool) {
-	req := openai.ChatCompletionRequest{
-		Model:    openai.GPT3Dot5Turbo, // Ou gpt-4, gpt-4-turbo-preview etc.
-		Messages: messages,
-		// Stream:   true, // On ne gère pas le streaming pour le moment pour l'Étape 0 simple
-		// Tools: availableTools, // Activer si vous voulez des tool calls
+// temperature convertit la température des options pour go-openai, qui omet une valeur nulle
+// (le serveur applique alors sa valeur par défaut) : 0 est envoyé comme la plus petite valeur
+// positive, comme le recommande go-openai.
+func (c *OpenAIChat) temperature() float32 {
+	switch {
+	case c.opts.Temperature == nil:
+		return 0
+	case *c.opts.Temperature == 0:
+		return math.SmallestNonzeroFloat32
+	default:
+		return *c.opts.Temperature
 	}
+}
 
-	log.Println("LLM: Envoi de la requête à OpenAI...")
-	resp, err := lp.client.CreateChatCompletion(ctx, req)
-
+func (c *OpenAIChat) Chat(ctx context.Context, req Request) (openai.ChatCompletionMessage, error) {
+	resp, err := c.client.CreateChatCompletion(ctx, c.request(req, false))
 	if err != nil {
-		lp.outputChan <- LLMResponse{Error: fmt.Errorf("erreur ChatCompletion: %w", err)}
-		return
+		return openai.ChatCompletionMessage{}, fmt.Errorf("erreur ChatCompletion: %w", err)
 	}
-
-	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" && len(resp.Choices[0].Message.ToolCalls) == 0 {
-		lp.outputChan <- LLMResponse{Error: errors.New("réponse LLM vide")}
-		return
-	}
-
-	choice := resp.Choices[0]
-	if len(choice.Message.ToolCalls) > 0 {
-		log.Printf("LLM: Reçu des ToolCalls: %+v", choice.Message.ToolCalls)
-		lp.outputChan <- LLMResponse{ToolCalls: choice.Message.ToolCalls}
-	} else {
-		log.Printf("LLM: Réponse reçue: %s", choice.Message.Content)
-		lp.outputChan <- LLMResponse{Content: choice.Message.Content}
+	if len(resp.Choices) == 0 {
+		return openai.ChatCompletionMessage{}, errors.New("réponse LLM vide")
 	}
+	msg := resp.Choices[0].Message
+	msg.ToolCalls = ensureToolCallIDs(msg.ToolCalls)
+	return msg, nil
 }
 
-// GetResponseStream (PLUS COMPLEXE, pour plus tard si besoin de latence encore plus faible pour la réponse)
-func (lp *LLMProcessor) GetResponseStream(ctx context.Context, messages []openai.ChatCompletionMessage) {
-	req := openai.ChatCompletionRequest{
-		Model:    openai.GPT3Dot5Turbo,
-		Messages: messages,
-		Stream:   true,
-	}
+func (c *OpenAIChat) ChatStream(ctx context.Context, req Request, onContent func(delta string) bool) (openai.ChatCompletionMessage, error) {
+	msg := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant}
 
-	stream, err := lp.client.CreateChatCompletionStream(ctx, req)
+	stream, err := c.client.CreateChatCompletionStream(ctx, c.request(req, true))
 	if err != nil {
-		lp.outputChan <- LLMResponse{Error: fmt.Errorf("erreur ChatCompletionStream: %w", err)}
-		return
+		return msg, fmt.Errorf("erreur ChatCompletionStream: %w", err)
 	}
 	defer stream.Close()
 
-	var fullResponse string
-	log.Println("LLM Stream: ")
+	var fullResponse strings.Builder
+	var toolCalls []openai.ToolCall
 	for {
 		response, err := stream.Recv()
 		if errors.Is(err, io.EOF) {
 			break
 		}
 		if err != nil {
-			lp.outputChan <- LLMResponse{Error: fmt.Errorf("erreur réception stream: %w", err)}
-			return
+			return msg, fmt.Errorf("erreur réception stream: %w", err)
+		}
+		if len(response.Choices) == 0 {
+			continue
+		}
+		delta := response.Choices[0].Delta
+		toolCalls = mergeToolCallDeltas(toolCalls, delta.ToolCalls)
+
+		if delta.Content == "" {
+			continue
 		}
-		content := response.Choices[0].Delta.Content
-		fullResponse += content
-		// Pour une interaction ultra-rapide, on pourrait envoyer des bouts de phrase au TTS ici.
-		// fmt.Print(content) // Ou envoyer vers un channel pour le TTS
+		fullResponse.WriteString(delta.Content)
+		if !onContent(delta.Content) {
+			msg.Content = fullResponse.String()
+			return msg, context.Canceled
+		}
+	}
+
+	msg.Content = fullResponse.String()
+	msg.ToolCalls = ensureToolCallIDs(toolCalls)
+	return msg, nil
+}
+
+// mergeToolCallDeltas réassemble les ToolCalls streamés : le premier fragment d'un appel
+// porte son Index, son ID et son nom, les suivants complètent les arguments JSON.
+func mergeToolCallDeltas(calls []openai.ToolCall, deltas []openai.ToolCall) []openai.ToolCall {
+	for _, d := range deltas {
+		idx := len(calls)
+		switch {
+		case d.Index != nil:
+			idx = *d.Index
+		case d.ID == "" && len(calls) > 0:
+			idx = len(calls) - 1 // Serveur compatible sans Index : suite de l'appel en cours
+		}
+		for len(calls) <= idx {
+			calls = append(calls, openai.ToolCall{Type: openai.ToolTypeFunction})
+		}
+		c := &calls[idx]
+		if d.ID != "" {
+			c.ID = d.ID
+		}
+		if d.Type != "" {
+			c.Type = d.Type
+		}
+		c.Function.Name += d.Function.Name
+		c.Function.Arguments += d.Function.Arguments
+	}
+	// Index n'a de sens que dans les fragments, il ne doit pas être renvoyé au modèle.
+	for i := range calls {
+		calls[i].Index = nil
 	}
-	// fmt.Println()
-	lp.outputChan <- LLMResponse{Content: fullResponse}
+	return calls
 }
@@ -0,0 +1,152 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"tars/actions"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+type LLMResponse struct {
+	Content   string
+	ToolCalls []openai.ToolCall
+	Error     error
+}
+
+type LLMProcessor struct {
+	model      LLM
+	router     *actions.ActionRouter // nil : pas de function calling
+	tools      []openai.Tool
+	outputChan chan LLMResponse
+}
+
+// NewLLMProcessor crée le processeur sur le backend model. Les outils proposés au modèle sont
+// ceux du registry du router ; si router est nil, il n'y a pas de function calling.
+// outputChan reçoit les réponses de GetResponse et StreamSentences ; RunAgent retourne la sienne
+// et n'en a pas besoin.
+func NewLLMProcessor(model LLM, router *actions.ActionRouter, outputChan chan LLMResponse) *LLMProcessor {
+	lp := &LLMProcessor{
+		model:      model,
+		router:     router,
+		outputChan: outputChan,
+	}
+	if router != nil {
+		lp.tools = ToolsFromRegistry(router.Registry())
+	}
+	return lp
+}
+
+func (lp *LLMProcessor) GetResponse(ctx context.Context, messages []openai.ChatCompletionMessage, availableTools []openai.Tool) {
+	msg, err := lp.complete(ctx, messages, availableTools)
+	if err != nil {
+		lp.send(ctx, LLMResponse{Error: err})
+		return
+	}
+
+	if msg.Content == "" && len(msg.ToolCalls) == 0 {
+		lp.send(ctx, LLMResponse{Error: errors.New("réponse LLM vide")})
+		return
+	}
+
+	if len(msg.ToolCalls) > 0 {
+		log.Printf("LLM: Reçu des ToolCalls: %+v", msg.ToolCalls)
+		lp.send(ctx, LLMResponse{ToolCalls: msg.ToolCalls})
+	} else {
+		log.Printf("LLM: Réponse reçue: %s", msg.Content)
+		lp.send(ctx, LLMResponse{Content: msg.Content})
+	}
+}
+
+// send publie une réponse sans bloquer indéfiniment si le consommateur est arrêté.
+func (lp *LLMProcessor) send(ctx context.Context, resp LLMResponse) {
+	select {
+	case lp.outputChan <- resp:
+	case <-ctx.Done():
+	}
+}
+
+// GetResponseStream (PLUS COMPLEXE, pour plus tard si besoin de latence encore plus faible pour la réponse)
+func (lp *LLMProcessor) GetResponseStream(ctx context.Context, messages []openai.ChatCompletionMessage) {
+	lp.StreamSentences(ctx, messages, nil)
+}
+
+// StreamSentences stream la réponse et envoie chaque morceau prononçable (phrase ou proposition)
+// sur chunkChan dès qu'il est complet, pour que le TTS commence sans attendre la fin de la réponse.
+// chunkChan (optionnel) est fermé à la fin ; la réponse complète (ou l'erreur) part ensuite sur outputChan.
+func (lp *LLMProcessor) StreamSentences(ctx context.Context, messages []openai.ChatCompletionMessage, chunkChan chan<- string) {
+	if chunkChan != nil {
+		defer close(chunkChan)
+	}
+
+	msg, err := lp.streamCompletion(ctx, messages, nil, func(chunk string) bool {
+		if chunkChan == nil {
+			return true
+		}
+		select {
+		case chunkChan <- chunk:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+	if err != nil {
+		lp.send(ctx, LLMResponse{Error: err})
+		return
+	}
+	lp.send(ctx, LLMResponse{Content: msg.Content})
+}
+
+// complete fait une requête non streamée et retourne le message du modèle.
+func (lp *LLMProcessor) complete(ctx context.Context, messages []openai.ChatCompletionMessage, availableTools []openai.Tool) (openai.ChatCompletionMessage, error) {
+	log.Printf("LLM: Envoi de la requête à %s...", lp.model.Name())
+	return lp.model.Chat(ctx, Request{Messages: messages, Tools: availableTools})
+}
+
+// streamCompletion fait une requête streamée : chaque morceau prononçable du texte est passé
+// à emit dès qu'il est complet (emit retourne false pour abandonner).
+// Retourne le message complet du modèle.
+func (lp *LLMProcessor) streamCompletion(
+	ctx context.Context,
+	messages []openai.ChatCompletionMessage,
+	availableTools []openai.Tool,
+	emit func(chunk string) bool,
+) (openai.ChatCompletionMessage, error) {
+	start := time.Now()
+	chunker := NewSentenceChunker()
+	firstChunk := true
+	aborted := false
+
+	log.Printf("LLM Stream: Envoi de la requête à %s...", lp.model.Name())
+	msg, err := lp.model.ChatStream(ctx, Request{Messages: messages, Tools: availableTools}, func(delta string) bool {
+		for _, chunk := range chunker.Push(delta) {
+			if firstChunk {
+				log.Printf("LLM Stream: Première phrase prête en %v.", time.Since(start))
+				firstChunk = false
+			}
+			if !emit(chunk) {
+				aborted = true
+				return false
+			}
+		}
+		return true
+	})
+	if aborted {
+		return msg, ctx.Err()
+	}
+	if err != nil {
+		return msg, err
+	}
+	if rest := chunker.Flush(); rest != "" && !emit(rest) {
+		return msg, ctx.Err()
+	}
+	log.Printf("LLM Stream: Réponse complète en %v.", time.Since(start))
+
+	if msg.Content == "" && len(msg.ToolCalls) == 0 {
+		return msg, errors.New("réponse LLM vide")
+	}
+	return msg, nil
+}
@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+	"github.com/sashabaranov/go-openai"
+)
+
+// Les tables BPE sont embarquées dans le binaire : aucun téléchargement au premier comptage.
+var setLoader sync.Once
+
+// defaultEncoding est utilisé pour les modèles inconnus de tiktoken (modèles locaux via Ollama,
+// llama.cpp...) : le compte n'est alors qu'une estimation, du bon ordre de grandeur.
+const defaultEncoding = "cl100k_base"
+
+// Surcoût de format par message et par réponse, d'après la documentation OpenAI.
+const (
+	tokensPerMessage  = 3
+	tokensPerName     = 1
+	tokensPerToolCall = 3
+	tokensPerReply    = 3
+)
+
+// Tokenizer compte les tokens des messages envoyés au modèle, localement.
+type Tokenizer struct {
+	enc *tiktoken.Tiktoken
+}
+
+// NewTokenizer charge l'encodage du modèle model, ou defaultEncoding s'il est inconnu.
+func NewTokenizer(model string) (*Tokenizer, error) {
+	setLoader.Do(func() { tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader()) })
+
+	enc, err := tiktoken.EncodingForModel(model)
+	if err != nil {
+		log.Printf("LLM Tokenizer: Modèle %q inconnu, comptage approximatif avec %s.", model, defaultEncoding)
+		enc, err = tiktoken.GetEncoding(defaultEncoding)
+		if err != nil {
+			return nil, fmt.Errorf("chargement tokenizer %s: %w", defaultEncoding, err)
+		}
+	}
+	return &Tokenizer{enc: enc}, nil
+}
+
+// Count retourne le nombre de tokens de text (les tokens spéciaux sont comptés comme du texte).
+func (t *Tokenizer) Count(text string) int {
+	if text == "" {
+		return 0
+	}
+	return len(t.enc.EncodeOrdinary(text))
+}
+
+// CountMessage retourne le coût d'un message dans une requête : rôle, contenu, tool calls et surcoût de format.
+func (t *Tokenizer) CountMessage(msg openai.ChatCompletionMessage) int {
+	n := tokensPerMessage + t.Count(msg.Role) + t.Count(msg.Content) + t.Count(msg.ToolCallID)
+	for _, part := range msg.MultiContent {
+		n += t.Count(part.Text)
+	}
+	if msg.Name != "" {
+		n += tokensPerName + t.Count(msg.Name)
+	}
+	for _, call := range msg.ToolCalls {
+		n += tokensPerToolCall + t.Count(call.ID) + t.Count(call.Function.Name) + t.Count(call.Function.Arguments)
+	}
+	return n
+}
+
+// CountMessages retourne le coût de messages dans une requête, amorce de la réponse comprise.
+func (t *Tokenizer) CountMessages(msgs []openai.ChatCompletionMessage) int {
+	n := tokensPerReply
+	for _, msg := range msgs {
+		n += t.CountMessage(msg)
+	}
+	return n
+}
+
+// CountTools estime le coût des définitions d'outils d'une requête : le serveur les ajoute au
+// prompt sous une forme proche de leur JSON (nom, description et schéma des paramètres).
+func (t *Tokenizer) CountTools(tools []openai.Tool) int {
+	n := 0
+	for _, tool := range tools {
+		if tool.Function == nil {
+			continue
+		}
+		data, err := json.Marshal(tool.Function)
+		if err != nil {
+			continue
+		}
+		n += t.Count(string(data))
+	}
+	return n
+}
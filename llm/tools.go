@@ -0,0 +1,27 @@
+package llm
+
+import (
+	"tars/actions"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// ToolsFromRegistry construit les définitions d'outils envoyées au modèle à partir des executors enregistrés.
+func ToolsFromRegistry(registry *actions.Registry) []openai.Tool {
+	executors := registry.Executors()
+	if len(executors) == 0 {
+		return nil
+	}
+	tools := make([]openai.Tool, 0, len(executors))
+	for _, e := range executors {
+		tools = append(tools, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        e.Name(),
+				Description: e.Description(),
+				Parameters:  e.Parameters(),
+			},
+		})
+	}
+	return tools
+}
@@ -0,0 +1,333 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const summarizePrompt = `Tu tiens à jour le résumé d'une conversation orale entre un utilisateur et TARS, un assistant vocal.
+Intègre les nouveaux échanges au résumé existant : ce que l'utilisateur a dit de lui, ses préférences et ses demandes,
+les réponses et décisions de TARS, les résultats d'outils encore utiles. Oublie les politesses et les détails sans suite.
+Réponds uniquement par le nouveau résumé, en texte brut, en quelques phrases (200 mots au plus).`
+
+// ContextWindow borne la taille des requêtes envoyées au modèle. Tant que l'historique tient
+// dans le budget, il est envoyé tel quel. Au-delà, les tours de parole les plus anciens sont
+// remplacés par un résumé, généré par le modèle et complété au fil de la conversation :
+// les messages système du début et les derniers tours restent intacts.
+//
+// Le résumé est complété par tranches qui tiennent chacune dans le budget : reprendre une longue
+// session (voir history) ne produit jamais de requête de résumé démesurée. Si le résumé échoue,
+// les tours les plus anciens sont simplement omis de la requête : aucune requête ne dépasse le budget.
+//
+// L'historique n'est coupé qu'avant un message de l'utilisateur (ou, en dernier recours, avant
+// un message qui n'est pas un résultat d'outil) : un message portant des tool calls reste donc
+// toujours suivi de ses résultats, comme l'exigent les API.
+//
+// Les définitions des outils proposés au modèle sont envoyées avec chaque requête : leur coût
+// est déduit du budget. Le résumé peut être enregistré avec la conversation (OnSummary) et
+// repris au redémarrage (Restore), pour ne pas résumer à nouveau toute une session reprise.
+type ContextWindow struct {
+	model     LLM
+	tokenizer *Tokenizer
+	budget    int // Tokens
+	keepTurns int // Tours de parole (message de l'utilisateur et suite) jamais résumés
+	toolCost  int // Tokens des définitions d'outils, envoyées avec chaque requête
+
+	mu        sync.Mutex
+	summary   string // Résumé des messages [head, covered) de l'historique
+	covered   int
+	restored  int                               // Messages couverts par le résumé repris, -1 sans reprise en attente
+	onSummary func(summary string, covered int) // Optionnel, voir OnSummary
+}
+
+// NewContextWindow crée une fenêtre de budget tokens qui résume avec model. tools sont les
+// outils proposés au modèle à chaque requête. Un budget de 0 désactive la gestion du contexte.
+func NewContextWindow(model LLM, tokenizer *Tokenizer, budget, keepTurns int, tools []openai.Tool) *ContextWindow {
+	w := &ContextWindow{
+		model:     model,
+		tokenizer: tokenizer,
+		budget:    budget,
+		keepTurns: max(keepTurns, 1),
+		toolCost:  tokenizer.CountTools(tools),
+		restored:  -1,
+	}
+	if budget > 0 && w.toolCost >= budget/2 {
+		log.Printf("LLM Context: Les %d outils coûtent %d tokens, pour un budget de %d.", len(tools), w.toolCost, budget)
+	}
+	return w
+}
+
+// Restore reprend un résumé enregistré, qui couvre les covered premiers messages de l'historique
+// après le prompt système (tel que passé à OnSummary). À appeler avant le premier Fit.
+func (w *ContextWindow) Restore(summary string, covered int) {
+	if w == nil || summary == "" {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.summary, w.restored = summary, covered
+}
+
+// OnSummary demande d'appeler fn à chaque mise à jour du résumé, avec le nombre de messages
+// de l'historique (après le prompt système) qu'il couvre, par exemple pour l'enregistrer.
+func (w *ContextWindow) OnSummary(fn func(summary string, covered int)) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onSummary = fn
+}
+
+// Fit retourne les messages à envoyer pour l'historique msgs (la conversation complète,
+// qui ne fait que s'allonger d'un appel à l'autre). Le résumé est mis à jour si le budget
+// est dépassé ; en cas d'échec (ou d'annulation via ctx), les tranches déjà résumées sont
+// gardées, les tours les plus anciens restants sont omis de cette requête, et la mise à jour
+// du résumé sera retentée au tour suivant.
+func (w *ContextWindow) Fit(ctx context.Context, msgs []openai.ChatCompletionMessage) []openai.ChatCompletionMessage {
+	if w == nil || w.budget <= 0 {
+		return msgs
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	head := leadingSystem(msgs)
+	if w.restored >= 0 {
+		w.covered, w.restored = head+w.restored, -1
+	}
+	if w.covered < head || w.covered > len(msgs) {
+		w.summary, w.covered = "", head // Autre historique : on repart de zéro
+	}
+	costs := make([]int, len(msgs))
+	for i, m := range msgs {
+		costs[i] = w.tokenizer.CountMessage(m)
+	}
+
+	total := w.cost(costs, head, w.covered)
+	if total <= w.budget {
+		return w.assemble(msgs, head, w.covered)
+	}
+
+	cut := w.cutPoint(msgs, costs, head)
+	if cut > w.covered {
+		before := w.covered
+		err := w.fold(ctx, msgs, costs, cut)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("LLM Context: Erreur de résumé: %v", err)
+		}
+		if w.covered > before {
+			log.Printf("LLM Context: %d messages résumés (%d tokens de résumé).", w.covered-before, w.tokenizer.Count(w.summary))
+			if w.onSummary != nil {
+				w.onSummary(w.summary, w.covered-head)
+			}
+		}
+	} else {
+		log.Printf("LLM Context: %d tokens pour un budget de %d, mais rien à résumer hors des %d derniers tours.",
+			total, w.budget, w.keepTurns)
+	}
+
+	from := w.covered
+	if w.cost(costs, head, from) > w.budget {
+		from = w.trim(msgs, costs, head)
+		log.Printf("LLM Context: %d messages anciens omis de la requête pour tenir dans le budget.", from-w.covered)
+	}
+	log.Printf("LLM Context: %d → %d tokens (budget %d).", total, w.cost(costs, head, from), w.budget)
+	return w.assemble(msgs, head, from)
+}
+
+// Summary retourne le résumé courant, vide tant que l'historique tient dans le budget.
+func (w *ContextWindow) Summary() string {
+	if w == nil {
+		return ""
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.summary
+}
+
+// assemble retourne les messages système du début, le résumé puis les messages à partir de from.
+func (w *ContextWindow) assemble(msgs []openai.ChatCompletionMessage, head, from int) []openai.ChatCompletionMessage {
+	out := make([]openai.ChatCompletionMessage, 0, len(msgs)-from+head+1)
+	out = append(out, msgs[:head]...)
+	if w.summary != "" {
+		out = append(out, w.summaryMessage())
+	}
+	return append(out, msgs[from:]...)
+}
+
+func (w *ContextWindow) summaryMessage() openai.ChatCompletionMessage {
+	return openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleSystem,
+		Content: "Résumé de la conversation jusqu'ici : " + w.summary,
+	}
+}
+
+// cost retourne le coût de la requête que produirait assemble(msgs, head, from), outils compris.
+func (w *ContextWindow) cost(costs []int, head, from int) int {
+	n := tokensPerReply + w.toolCost + sum(costs[:head]) + sum(costs[from:])
+	if w.summary != "" {
+		n += w.tokenizer.CountMessage(w.summaryMessage())
+	}
+	return n
+}
+
+// cutPoint choisit où s'arrête la partie résumée : le début de tour le plus ancien à partir
+// duquel la fin de l'historique tient dans la moitié du budget (pour ne pas résumer à chaque
+// tour), sans entamer les keepTurns derniers tours. Retourne w.covered si rien n'est résumable.
+func (w *ContextWindow) cutPoint(msgs []openai.ChatCompletionMessage, costs []int, head int) int {
+	target := w.budget/2 - w.toolCost - sum(costs[:head])
+	cut := w.covered
+	turns := 0
+	tokens := 0
+	for i := len(msgs) - 1; i > w.covered && i >= head; i-- {
+		tokens += costs[i]
+		if msgs[i].Role != openai.ChatMessageRoleUser {
+			continue
+		}
+		turns++
+		if turns < w.keepTurns {
+			continue
+		}
+		if turns == w.keepTurns || tokens <= target {
+			cut = i // Le plus ancien début de tour acceptable jusqu'ici
+			continue
+		}
+		break
+	}
+	return cut
+}
+
+// fold intègre msgs[w.covered:cut] au résumé, par tranches de tours entiers dont le
+// transcript tient dans la moitié du budget (le reste est pour le prompt et le résumé).
+// Chaque tranche résumée fait avancer w.covered, même si une tranche suivante échoue.
+func (w *ContextWindow) fold(ctx context.Context, msgs []openai.ChatCompletionMessage, costs []int, cut int) error {
+	limit := w.budget / 2
+	for w.covered < cut {
+		end := w.chunkEnd(msgs, costs, cut, limit)
+		summary, err := w.summarize(ctx, msgs[w.covered:end])
+		if err != nil {
+			return err
+		}
+		w.summary, w.covered = summary, end
+	}
+	return nil
+}
+
+// chunkEnd retourne la fin de la prochaine tranche à résumer : cut si tout tient dans limit,
+// sinon le début de tour le plus lointain qui y tient, et au moins un tour entier.
+func (w *ContextWindow) chunkEnd(msgs []openai.ChatCompletionMessage, costs []int, cut, limit int) int {
+	best, first := 0, 0
+	tokens := 0
+	for i := w.covered; i < cut; i++ {
+		if i > w.covered && msgs[i].Role == openai.ChatMessageRoleUser {
+			if first == 0 {
+				first = i
+			}
+			if tokens > limit {
+				break
+			}
+			best = i
+		}
+		tokens += costs[i]
+	}
+	switch {
+	case tokens <= limit:
+		return cut
+	case best > 0:
+		return best
+	case first > 0:
+		return first // Un seul tour dépasse limit : résumé seul
+	default:
+		return cut
+	}
+}
+
+// trim retourne le premier message à envoyer pour que la requête tienne dans le budget sans
+// nouveau résumé : le début de tour le plus ancien qui convient, sinon le message le plus ancien
+// qui n'est pas un résultat d'outil (on ne garde alors qu'une partie du dernier tour), et au pire
+// le dernier message seul.
+func (w *ContextWindow) trim(msgs []openai.ChatCompletionMessage, costs []int, head int) int {
+	for _, ok := range []func(openai.ChatCompletionMessage) bool{
+		func(m openai.ChatCompletionMessage) bool { return m.Role == openai.ChatMessageRoleUser },
+		func(m openai.ChatCompletionMessage) bool { return m.Role != openai.ChatMessageRoleTool },
+	} {
+		for i := w.covered + 1; i < len(msgs); i++ {
+			if ok(msgs[i]) && w.cost(costs, head, i) <= w.budget {
+				return i
+			}
+		}
+	}
+	last := len(msgs) - 1
+	for last > w.covered && msgs[last].Role == openai.ChatMessageRoleTool {
+		last--
+	}
+	log.Printf("LLM Context: Le dernier message dépasse à lui seul le budget de %d tokens.", w.budget)
+	return max(last, w.covered)
+}
+
+func sum(values []int) int {
+	n := 0
+	for _, v := range values {
+		n += v
+	}
+	return n
+}
+
+// summarize intègre msgs au résumé courant. Les messages sont mis en forme comme un
+// transcript, pour que le modèle n'ait pas à interpréter des tool calls sans outils déclarés.
+func (w *ContextWindow) summarize(ctx context.Context, msgs []openai.ChatCompletionMessage) (string, error) {
+	var b strings.Builder
+	if w.summary != "" {
+		fmt.Fprintf(&b, "Résumé existant :\n%s\n\n", w.summary)
+	}
+	b.WriteString("Nouveaux échanges :\n")
+	writeTranscript(&b, msgs)
+
+	reply, err := w.model.Chat(ctx, Request{Messages: []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: summarizePrompt},
+		{Role: openai.ChatMessageRoleUser, Content: b.String()},
+	}})
+	if err != nil {
+		return "", err
+	}
+	summary := strings.TrimSpace(reply.Content)
+	if summary == "" {
+		return "", errors.New("résumé vide")
+	}
+	return summary, nil
+}
+
+func writeTranscript(b *strings.Builder, msgs []openai.ChatCompletionMessage) {
+	for _, m := range msgs {
+		switch m.Role {
+		case openai.ChatMessageRoleUser:
+			fmt.Fprintf(b, "Utilisateur : %s\n", m.Content)
+		case openai.ChatMessageRoleAssistant:
+			if m.Content != "" {
+				fmt.Fprintf(b, "TARS : %s\n", m.Content)
+			}
+			for _, call := range m.ToolCalls {
+				fmt.Fprintf(b, "TARS appelle l'outil %s(%s)\n", call.Function.Name, strings.TrimSpace(call.Function.Arguments))
+			}
+		case openai.ChatMessageRoleTool:
+			fmt.Fprintf(b, "Résultat de l'outil : %s\n", m.Content)
+		default:
+			fmt.Fprintf(b, "%s : %s\n", m.Role, m.Content)
+		}
+	}
+}
+
+// leadingSystem retourne le nombre de messages système en tête de l'historique (prompt système).
+func leadingSystem(msgs []openai.ChatCompletionMessage) int {
+	n := 0
+	for n < len(msgs) && msgs[n].Role == openai.ChatMessageRoleSystem {
+		n++
+	}
+	return n
+}
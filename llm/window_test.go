@@ -0,0 +1,185 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// summarizer est un LLM factice qui résume (ou échoue) et mesure chaque requête.
+type summarizer struct {
+	tokenizer *Tokenizer
+	fail      bool
+	calls     int
+	maxTokens int // Plus grosse requête reçue
+}
+
+func (s *summarizer) Name() string { return "test" }
+
+func (s *summarizer) Chat(_ context.Context, req Request) (openai.ChatCompletionMessage, error) {
+	s.calls++
+	s.maxTokens = max(s.maxTokens, s.tokenizer.CountMessages(req.Messages))
+	if s.fail {
+		return openai.ChatCompletionMessage{}, errors.New("indisponible")
+	}
+	return openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: fmt.Sprintf("résumé %d", s.calls)}, nil
+}
+
+func (s *summarizer) ChatStream(ctx context.Context, req Request, _ func(string) bool) (openai.ChatCompletionMessage, error) {
+	return s.Chat(ctx, req)
+}
+
+// history construit une conversation de turns tours, avec un aller-retour d'outil un tour sur trois.
+func history(turns int) []openai.ChatCompletionMessage {
+	filler := strings.Repeat("bla ", 30)
+	msgs := []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleSystem, Content: "Tu es TARS."}}
+	for t := 0; t < turns; t++ {
+		msgs = append(msgs, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: fmt.Sprintf("question %d %s", t, filler)})
+		if t%3 == 0 {
+			id := fmt.Sprintf("call_%d", t)
+			msgs = append(msgs,
+				openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, ToolCalls: []openai.ToolCall{
+					{ID: id, Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "heure", Arguments: "{}"}},
+				}},
+				openai.ChatCompletionMessage{Role: openai.ChatMessageRoleTool, ToolCallID: id, Content: "15h04"})
+		}
+		msgs = append(msgs, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: "réponse " + filler})
+	}
+	return append(msgs, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: "dernière question"})
+}
+
+func newTestWindow(t *testing.T, fail bool, budget int, tools ...openai.Tool) (*ContextWindow, *summarizer) {
+	t.Helper()
+	tokenizer, err := NewTokenizer("gpt-4o")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &summarizer{tokenizer: tokenizer, fail: fail}
+	return NewContextWindow(s, tokenizer, budget, 2, tools), s
+}
+
+// checkRequest vérifie le budget (outils compris), le prompt système en tête et l'appariement des tool calls.
+func checkRequest(t *testing.T, w *ContextWindow, out []openai.ChatCompletionMessage) {
+	t.Helper()
+	if n := w.tokenizer.CountMessages(out) + w.toolCost; n > w.budget {
+		t.Errorf("requête de %d tokens pour un budget de %d", n, w.budget)
+	}
+	if out[0].Content != "Tu es TARS." {
+		t.Errorf("prompt système perdu : %q", out[0].Content)
+	}
+	pending := map[string]bool{}
+	for i, m := range out {
+		for _, call := range m.ToolCalls {
+			pending[call.ID] = true
+		}
+		if m.Role == openai.ChatMessageRoleTool {
+			if !pending[m.ToolCallID] {
+				t.Errorf("message %d : résultat d'outil %s sans tool call", i, m.ToolCallID)
+			}
+			delete(pending, m.ToolCallID)
+		}
+	}
+	if len(pending) > 0 {
+		t.Errorf("tool calls sans résultat : %v", pending)
+	}
+	if last := out[len(out)-1]; last.Content != "dernière question" {
+		t.Errorf("dernier message perdu : %q", last.Content)
+	}
+}
+
+func TestContextWindowResumedSession(t *testing.T) {
+	// Session reprise : tout l'historique dépasse le budget dès le premier tour.
+	w, s := newTestWindow(t, false, 600)
+	out := w.Fit(context.Background(), history(40))
+	checkRequest(t, w, out)
+	if s.calls < 2 {
+		t.Errorf("%d requête(s) de résumé, attendu un résumé par tranches", s.calls)
+	}
+	if s.maxTokens > w.budget {
+		t.Errorf("requête de résumé de %d tokens pour un budget de %d", s.maxTokens, w.budget)
+	}
+	if w.Summary() == "" {
+		t.Error("pas de résumé")
+	}
+}
+
+func TestContextWindowSummaryFailure(t *testing.T) {
+	w, s := newTestWindow(t, true, 600)
+	msgs := history(40)
+	for range 2 { // L'échec se répète au tour suivant sans jamais dépasser le budget
+		out := w.Fit(context.Background(), msgs)
+		checkRequest(t, w, out)
+		if s.maxTokens > w.budget {
+			t.Errorf("requête de résumé de %d tokens pour un budget de %d", s.maxTokens, w.budget)
+		}
+	}
+	if w.Summary() != "" {
+		t.Errorf("résumé inattendu : %q", w.Summary())
+	}
+}
+
+func TestContextWindowUnderBudget(t *testing.T) {
+	w, s := newTestWindow(t, false, 100000)
+	msgs := history(5)
+	if out := w.Fit(context.Background(), msgs); len(out) != len(msgs) {
+		t.Errorf("%d messages envoyés sur %d", len(out), len(msgs))
+	}
+	if s.calls != 0 {
+		t.Errorf("%d résumé(s) inutile(s)", s.calls)
+	}
+}
+
+func TestContextWindowCountsTools(t *testing.T) {
+	var tools []openai.Tool
+	for i := range 4 {
+		tools = append(tools, openai.Tool{Type: openai.ToolTypeFunction, Function: &openai.FunctionDefinition{
+			Name:        fmt.Sprintf("outil_%d", i),
+			Description: "Un outil à la description assez longue pour peser dans le budget. " + strings.Repeat("détail ", 10),
+			Parameters: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"ville": map[string]any{"type": "string", "description": "Nom de la ville"}},
+			},
+		}})
+	}
+	w, s := newTestWindow(t, false, 500, tools...)
+	if w.toolCost < 150 {
+		t.Fatalf("outils comptés pour %d tokens seulement", w.toolCost)
+	}
+	// Sans les outils, cet historique tiendrait dans le budget.
+	msgs := history(4)
+	if n := w.tokenizer.CountMessages(msgs); n > w.budget || n+w.toolCost <= w.budget {
+		t.Fatalf("historique de %d tokens (+%d d'outils) : test mal calibré pour un budget de %d", n, w.toolCost, w.budget)
+	}
+	checkRequest(t, w, w.Fit(context.Background(), msgs))
+	if s.calls == 0 {
+		t.Error("pas de résumé alors que les outils font dépasser le budget")
+	}
+}
+
+func TestContextWindowRestore(t *testing.T) {
+	msgs := history(40)
+	w, _ := newTestWindow(t, false, 600)
+	var saved string
+	var covered int
+	w.OnSummary(func(summary string, n int) { saved, covered = summary, n })
+	w.Fit(context.Background(), msgs)
+	if saved == "" || saved != w.Summary() || covered <= 0 || covered >= len(msgs)-1 {
+		t.Fatalf("résumé enregistré %q couvrant %d messages", saved, covered)
+	}
+
+	// Session reprise : le résumé enregistré évite de tout résumer à nouveau.
+	resumed, s := newTestWindow(t, false, 600)
+	resumed.Restore(saved, covered)
+	out := resumed.Fit(context.Background(), msgs)
+	checkRequest(t, resumed, out)
+	if s.calls != 0 {
+		t.Errorf("%d requête(s) de résumé après reprise, attendu aucune", s.calls)
+	}
+	if out[1].Content != "Résumé de la conversation jusqu'ici : "+saved {
+		t.Errorf("résumé repris absent de la requête : %q", out[1].Content)
+	}
+}
@@ -1,154 +1,111 @@
-// main.go (ou autre)
+// Commande tars : l'assistant complet (tars run, par défaut) et une sous-commande par étape
+// du pipeline, pour tester et déboguer chacune isolément.
 package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
-	"time" // Pour le goroutine principale d'exemple
 
-	"tars/audio"
 	"tars/config"
 
-	// "tars/llm"
-	// "tars/stt"
-
 	"github.com/gordonklaus/portaudio"
 )
 
-// Helper pour convertir PCM []int16 (mono) en []byte pour le VAD
-func pcm16MonoToBytes(pcm []int16) []byte {
-	buf := make([]byte, len(pcm)*2) // BytesPerSample = 2
-	for i, s := range pcm {
-		buf[i*2] = byte(s & 0xFF)          // Little-endian LSB
-		buf[i*2+1] = byte((s >> 8) & 0xFF) // Little-endian MSB
+// command est une sous-commande ; run reçoit les arguments qui suivent son nom.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands []command
+
+func init() {
+	// Dans init() pour éviter un cycle d'initialisation : l'aide de runAssistant parcourt commands.
+	commands = []command{
+		{"run", "assistant vocal complet (commande par défaut)", runAssistant},
+		{"chat", "conversation au clavier, sans micro ni haut-parleurs", runChat},
+		{"record", "enregistre les énoncés détectés par le VAD dans des fichiers WAV", runRecord},
+		{"transcribe", "transcrit des fichiers WAV avec le STT configuré", runTranscribe},
+		{"say", "prononce un texte avec le TTS configuré (haut-parleurs ou fichier WAV)", runSay},
+		{"devices", "liste les périphériques audio", runDevices},
+		{"history", "consulte ou supprime les conversations enregistrées", runHistory},
 	}
-	return buf
 }
 
 func main() {
-	log.Println("Démarrage de TARS...")
-
-	// --- Initialisation PortAudio (UNE FOIS) ---
-	if err := portaudio.Initialize(); err != nil {
-		log.Fatalf("TARS: Erreur initialisation PortAudio: %v", err)
+	args := os.Args[1:]
+	name := "run" // `tars -input question.wav` reste l'assistant complet
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
-	defer portaudio.Terminate() // Assurer la terminaison à la fin de main
-	log.Println("TARS: PortAudio initialisé.")
-	// --- Fin Initialisation PortAudio ---
-
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// Gestion des signaux d'arrêt (Ctrl+C)
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
-		<-sigChan
-		log.Println("TARS: Signal d'arrêt reçu, nettoyage...")
-		cancel()
-	}()
-
-	// --- Canaux de communication ---
-	audioFromCaptureChan := make(chan []int16, 50) // Buffer pour les frames capturées (int16)
-	//vadDetectChan := make(chan bool)             // true si speech, false si silence (ou pour fin de parole)
-	speechStopChan := make(chan bool, 1) // Pour signaler la *fin* de la parole (bufferisé)
-	// textFromSTTChan := make(chan string)
-	// llmResponseChan := make(chan string)
-	// audioPCMForPlayerChan := make(chan []byte)
-
-	// --- Modules ---
-	// 1. AudioCapturer
-	// Utilise VADFrameDurationMs de config car le VAD traitera ces frames.
-	// Channels DOIT être 1 pour go-webrtcvad.
-	capturer, err := audio.NewAudioCapturer(
-		config.SampleRate,
-		config.Channels, // DOIT être 1 pour VAD actuel
-		config.VADFrameDurationMs,
-		audioFromCaptureChan,
-	)
-
-	if err != nil {
-		log.Fatalf("TARS: Erreur création AudioCapturer: %v", err)
+	if name == "help" {
+		usage(os.Stdout)
+		return
 	}
-	go capturer.Start(ctx) // Démarre la capture dans une goroutine
 
-	// 2. VADProcessor
-	/*
-		vadProcessor, err := audio.NewVADProcessor(speechStopChan)
-		// speechStopChan est pour indiquer la fin de parole
-		if err != nil {
-			log.Fatalf("TARS: Erreur création VADProcessor: %v", err)
+	for _, c := range commands {
+		if c.name != name {
+			continue
 		}
-		defer vadProcessor.Close() // Pas d'action pour go-webrtcvad mais bonne pratique
-	*/
-
-	// Boucle principale de traitement de l'audio capturé pour VAD
-	/*
-		go func() {
-			for {
-				select {
-				case <-ctx.Done():
-					log.Println("TARS VAD Loop: Contexte annulé, arrêt.")
-					return
-				case pcmFrame16, ok := <-audioFromCaptureChan:
-					if !ok {
-						log.Println("TARS VAD Loop: Canal de capture fermé.")
-						return // Le canal de capture est fermé, la goroutine doit s'arrêter
-					}
-
-					// Assurer que la frame est mono. Si config.Channels > 1, il faudrait une conversion ici.
-					// Pour l'instant, on suppose config.Channels = 1.
-					if len(pcmFrame16) == 0 {
-						// log.Println("TARS VAD Loop: Frame PCM16 vide reçue, ignorée.")
-						continue
-					}
-
-					// Convertir []int16 en []byte pour le VAD
-					byteFrame := pcm16MonoToBytes(pcmFrame16)
-
-					// Passer au VAD
-					// Le VAD a besoin de connaître le SampleRate avec lequel les données ont été capturées
-					isSpeaking, err := vadProcessor.Process(byteFrame, config.SampleRate)
-					if err != nil {
-						log.Printf("TARS VAD Loop: Erreur traitement VAD: %v", err)
-						// Décider quoi faire en cas d'erreur VAD, peut-être continuer
-						continue
-					}
+		if err := c.run(args); err != nil && !errors.Is(err, flag.ErrHelp) {
+			log.Fatalf("TARS: %v", err)
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Commande inconnue %q.\n\n", name)
+	usage(os.Stderr)
+	os.Exit(2)
+}
 
-					if isSpeaking {
-						// log.Printf("TARS VAD Loop: Parole détectée.")
-						// Ici, vous accumuleriez `byteFrame` pour STT jusqu'à ce que `speechStopChan` reçoive un signal
-					}
-					// La logique de "fin de parole" (envoyer sur speechStopChan) est gérée DANS vadProcessor.Process()
-				}
-			}
-		}()
-	*/
+// usage liste les sous-commandes.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage : tars [COMMANDE] [OPTIONS]")
+	fmt.Fprintln(w)
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-11s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "`tars COMMANDE -h` détaille les options d'une commande.")
+}
 
-	// Boucle de gestion de la fin de parole (exemple)
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				log.Println("TARS Speech Handler: Contexte annulé, arrêt.")
-				return
-			case <-speechStopChan:
-				log.Println("TARS Speech Handler: FIN DE PAROLE DÉTECTÉE (par VAD). C'est ici qu'on lancerait STT.")
-				// Logique pour prendre l'audio accumulé et l'envoyer au STT
-			}
-		}
-	}()
+// newFlagSet crée le jeu d'options d'une sous-commande ; args décrit ses arguments dans l'aide.
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage : tars %s [OPTIONS] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
 
-	log.Println("TARS est initialisé et à l'écoute. Appuyez sur Ctrl+C pour quitter.")
-	// Garder le programme principal en vie jusqu'à ce que le contexte soit annulé
-	<-ctx.Done()
+// signalContext retourne un contexte annulé par Ctrl+C ou SIGTERM.
+func signalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+}
 
-	log.Println("TARS: Programme principal en cours d'arrêt...")
-	// Attendre un peu pour que les goroutines aient une chance de se nettoyer si nécessaire,
-	// bien que ctx.Done() devrait le gérer.
-	time.Sleep(1 * time.Second)
-	log.Println("TARS: Arrêt terminé.")
+// initPortAudio initialise PortAudio si la commande en a besoin : capture depuis le micro
+// (config.AudioInput), ou lecture sur un périphérique choisi (config.OutputDevice ; sinon oto
+// joue sur la sortie système sans PortAudio). Retourne la fonction de terminaison.
+func initPortAudio(capture, playback bool) (terminate func(), err error) {
+	input := strings.TrimSpace(config.AudioInput)
+	output := strings.TrimSpace(config.AudioOutput)
+	needed := capture && (input == "" || input == "mic") ||
+		playback && (output == "" || output == "speaker") && config.OutputDevice != ""
+	if !needed {
+		return func() {}, nil
+	}
+	if err := portaudio.Initialize(); err != nil {
+		return nil, fmt.Errorf("initialisation PortAudio: %w", err)
+	}
+	log.Println("TARS: PortAudio initialisé.")
+	return func() { portaudio.Terminate() }, nil
 }
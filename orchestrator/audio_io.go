@@ -0,0 +1,77 @@
+package orchestrator
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"tars/audio"
+	"tars/audio/convert"
+	"tars/config"
+)
+
+// NewSink crée la sortie audio choisie par config.AudioOutput : les haut-parleurs (oto, ou
+// PortAudio si config.OutputDevice est défini), un fichier WAV, ou rien ("null").
+func NewSink(pcmChan chan convert.Chunk) (audio.AudioSink, error) {
+	output := strings.TrimSpace(config.AudioOutput)
+	switch output {
+	case "", "speaker":
+		return audio.NewAudioPlayer(pcmChan, config.PlayerSampleRate, config.PlayerChannels, config.PlayerBufferMs, config.OutputDevice)
+	case "null":
+		return audio.NewNullSink(pcmChan, config.PlayerSampleRate, config.PlayerChannels), nil
+	default:
+		return audio.NewWAVFileSink(pcmChan, output, config.PlayerSampleRate, config.PlayerChannels)
+	}
+}
+
+// NewSource crée la source audio choisie par config.AudioInput : le micro, un fichier WAV
+// ou du PCM brut sur l'entrée standard. Toutes produisent des frames mono à config.SampleRate.
+func NewSource(frameChan chan<- []int16) (audio.AudioSource, error) {
+	input := strings.TrimSpace(config.AudioInput)
+	switch {
+	case input == "" || input == "mic":
+		downmix, err := audio.ParseDownmixMode(config.CaptureDownmix)
+		if err != nil {
+			return nil, fmt.Errorf("configuration capture: %w", err)
+		}
+		return audio.NewAudioCapturer(
+			config.InputDevice,
+			config.SampleRate,
+			config.CaptureSampleRate,
+			config.CaptureChannels,
+			audio.Downmix{Mode: downmix, Channel: config.CaptureChannel},
+			config.VADFrameDurationMs,
+			frameChan,
+		)
+	case input == "-":
+		format := convert.Format{SampleRate: config.StdinSampleRate, Channels: config.StdinChannels, Sample: convert.Int16}
+		return audio.NewPCMReaderSource(os.Stdin, format, config.SampleRate, config.VADFrameDurationMs, frameChan)
+	default:
+		return audio.NewWAVFileSource(input, config.SampleRate, config.VADFrameDurationMs,
+			config.AudioInputRealtime, config.AudioInputLoop, frameChan)
+	}
+}
+
+// NewSegmenter crée le VAD et le segmenteur configurés (config.VAD*), qui découpe les frames
+// de frameChan en énoncés sur utteranceChan. L'appelant ferme le VAD après usage.
+func NewSegmenter(frameChan <-chan []int16, utteranceChan chan<- audio.Utterance) (*audio.Segmenter, *audio.VAD, error) {
+	vad, err := audio.NewVADWithSampleRate(config.VADAggressiveness, config.SampleRate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("création VAD: %w", err)
+	}
+	segmenter, err := audio.NewSegmenter(
+		vad,
+		config.VADFrameDurationMs,
+		config.VADSpeechFrames,
+		config.VADSilenceFrames,
+		config.VADPreRollMs,
+		config.VADMaxUtteranceMs,
+		frameChan,
+		utteranceChan,
+	)
+	if err != nil {
+		vad.Close()
+		return nil, nil, fmt.Errorf("création Segmenter: %w", err)
+	}
+	return segmenter, vad, nil
+}
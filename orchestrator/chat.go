@@ -0,0 +1,170 @@
+package orchestrator
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+
+	"tars/llm"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// maxToolResultRunes borne l'affichage des résultats d'outils dans le terminal.
+const maxToolResultRunes = 300
+
+// NewChat instancie TARS en mode texte : les lignes lues sur in remplacent le micro et le STT,
+// les réponses et les appels d'outils sont écrits sur out. L'historique, la boucle d'outils et
+// la persona sont les mêmes qu'en mode vocal. Avec speak, les réponses sont aussi prononcées
+// sur la sortie audio configurée (config.AudioOutput).
+func NewChat(client *openai.Client, in io.Reader, out io.Writer, speak bool) (*Orchestrator, error) {
+	o := newOrchestrator(client)
+	if err := o.initConversation(); err != nil {
+		return nil, err
+	}
+	if speak {
+		if err := o.initSpeech(); err != nil {
+			return nil, err
+		}
+	}
+	o.chat = newChatIO(in, out)
+	return o, nil
+}
+
+// runChat remplace la capture, la segmentation, le STT et l'étape LLM : chaque ligne saisie
+// est traitée comme une transcription, puis on attend la fin de la réponse (affichée, et
+// jouée si elle est prononcée) avant de rendre la main. La fin de l'entrée (Ctrl+D) arrête TARS.
+func (o *Orchestrator) runChat(ctx context.Context) {
+	for {
+		line, ok := o.chat.readLine(ctx)
+		if !ok {
+			if ctx.Err() == nil {
+				log.Println("TARS Orchestrator: Fin de l'entrée, conversation terminée.")
+				o.cancel()
+			}
+			return
+		}
+		text := strings.TrimSpace(line)
+		if text == "" {
+			continue
+		}
+		t := o.startTurn(ctx) // Avant AddUser : la réponse interrompue est tronquée à sa place
+		o.conversation.AddUser(text)
+		o.ask(ctx, t)
+		if !o.waitIdle(ctx) {
+			return
+		}
+	}
+}
+
+// chatIO est le terminal du mode texte : invite de saisie, réponse affichée morceau par
+// morceau au fil du streaming, appels d'outils et erreurs.
+type chatIO struct {
+	lines <-chan string // Fermé à la fin de l'entrée
+	out   io.Writer
+
+	mu      sync.Mutex
+	midLine bool // Une réponse est en cours d'affichage sur la ligne courante
+}
+
+// newChatIO lit in ligne par ligne dans une goroutine, pour qu'un arrêt ne reste pas bloqué
+// sur la lecture du terminal.
+func newChatIO(in io.Reader, out io.Writer) *chatIO {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		if err := scanner.Err(); err != nil {
+			log.Printf("TARS Chat: Erreur de lecture: %v", err)
+		}
+	}()
+	return &chatIO{lines: lines, out: out}
+}
+
+// readLine affiche l'invite et attend une ligne. ok est faux à la fin de l'entrée ou à l'arrêt.
+func (c *chatIO) readLine(ctx context.Context) (line string, ok bool) {
+	c.printf("Vous > ")
+	select {
+	case line, ok = <-c.lines:
+		if !ok {
+			c.printf("\n")
+		}
+		return line, ok
+	case <-ctx.Done():
+		c.printf("\n")
+		return "", false
+	}
+}
+
+// reply affiche un morceau de la réponse à la suite des précédents.
+func (c *chatIO) reply(text string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.midLine {
+		fmt.Fprint(c.out, " "+text)
+		return
+	}
+	fmt.Fprint(c.out, "TARS > "+text)
+	c.midLine = true
+}
+
+// toolRound affiche les outils appelés par le modèle et leurs résultats.
+func (c *chatIO) toolRound(round *llm.ToolRound) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.endLine()
+	results := make(map[string]string, len(round.Results))
+	for _, res := range round.Results {
+		results[res.ToolCallID] = res.Content
+	}
+	for _, call := range round.Assistant.ToolCalls {
+		fmt.Fprintf(c.out, "  [outil] %s(%s)\n", call.Function.Name, strings.TrimSpace(call.Function.Arguments))
+		if res, ok := results[call.ID]; ok {
+			fmt.Fprintf(c.out, "       → %s\n", truncateRunes(res, maxToolResultRunes))
+		}
+	}
+}
+
+// error affiche une erreur du LLM à la place de la réponse.
+func (c *chatIO) error(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.endLine()
+	fmt.Fprintf(c.out, "[erreur] %v\n", err)
+}
+
+// endReply termine la ligne de la réponse en cours.
+func (c *chatIO) endReply() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.endLine()
+}
+
+func (c *chatIO) endLine() {
+	if c.midLine {
+		fmt.Fprintln(c.out)
+		c.midLine = false
+	}
+}
+
+func (c *chatIO) printf(format string, args ...any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(c.out, format, args...)
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
@@ -0,0 +1,148 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"tars/actions"
+	"tars/config"
+	"tars/llm"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// scriptedLLM rejoue des réponses préparées, une par requête, et garde les requêtes reçues.
+type scriptedLLM struct {
+	replies  []openai.ChatCompletionMessage
+	requests []llm.Request
+}
+
+func (s *scriptedLLM) Name() string { return "test" }
+
+func (s *scriptedLLM) Chat(_ context.Context, req llm.Request) (openai.ChatCompletionMessage, error) {
+	s.requests = append(s.requests, req)
+	if len(s.replies) == 0 {
+		return openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: "Plus rien à dire."}, nil
+	}
+	msg := s.replies[0]
+	s.replies = s.replies[1:]
+	return msg, nil
+}
+
+func (s *scriptedLLM) ChatStream(ctx context.Context, req llm.Request, onContent func(string) bool) (openai.ChatCompletionMessage, error) {
+	msg, err := s.Chat(ctx, req)
+	if err == nil && msg.Content != "" {
+		onContent(msg.Content)
+	}
+	return msg, err
+}
+
+// newTestChat crée le mode texte sans historique persistant ni fenêtre de contexte,
+// puis remplace le LLM par model et les outils par un registry de test.
+func newTestChat(t *testing.T, input string, out *strings.Builder, model llm.LLM, registry *actions.Registry) *Orchestrator {
+	t.Helper()
+	prevHistory, prevBudget, prevStreaming := config.HistoryPath, config.LLMContextBudget, config.LLMStreaming
+	prevProvider, prevTemplate := config.LLMProvider, config.PromptTemplate
+	t.Cleanup(func() {
+		config.HistoryPath, config.LLMContextBudget, config.LLMStreaming = prevHistory, prevBudget, prevStreaming
+		config.LLMProvider, config.PromptTemplate = prevProvider, prevTemplate
+	})
+	config.HistoryPath, config.LLMContextBudget, config.LLMStreaming = "", 0, true
+	config.LLMProvider, config.PromptTemplate = "openai", ""
+
+	o, err := NewChat(openai.NewClient("test"), strings.NewReader(input), out, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.router = actions.NewActionRouter(registry)
+	o.llm = llm.NewLLMProcessor(model, o.router, nil)
+	return o
+}
+
+// runChatUntilEOF fait tourner o et vérifie qu'il s'arrête seul à la fin de l'entrée.
+func runChatUntilEOF(t *testing.T, o *Orchestrator) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := o.Run(ctx); err != nil {
+		t.Fatalf("Run : %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("le chat ne s'est pas arrêté à la fin de l'entrée")
+	}
+}
+
+func TestChatToolRoundAndReplies(t *testing.T) {
+	long := strings.Repeat("é", 400)
+	registry := actions.NewRegistry()
+	echo := actions.NewExecutor("echo", "Répète un long texte.", func(context.Context, struct{}) (any, error) { return long, nil })
+	if err := registry.Register(echo); err != nil {
+		t.Fatal(err)
+	}
+	model := &scriptedLLM{replies: []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleAssistant, ToolCalls: []openai.ToolCall{
+			{ID: "call_1", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "echo", Arguments: " {} "}},
+		}},
+		{Role: openai.ChatMessageRoleAssistant, Content: "Il est quinze heures. Bonne journée."},
+		{Role: openai.ChatMessageRoleAssistant, Content: "De rien."},
+	}}
+
+	var out strings.Builder
+	o := newTestChat(t, "Quelle heure est-il ?\n   \nMerci\n", &out, model, registry)
+	runChatUntilEOF(t, o)
+
+	// Résultat JSON de l'outil (guillemet compris) coupé à maxToolResultRunes runes.
+	result := `"` + strings.Repeat("é", maxToolResultRunes-1) + "…"
+	want := "Vous > " +
+		"  [outil] echo({})\n" +
+		"       → " + result + "\n" +
+		"TARS > Il est quinze heures. Bonne journée.\n" +
+		"Vous > Vous > " + // Ligne vide ignorée
+		"TARS > De rien.\n" +
+		"Vous > \n"
+	if out.String() != want {
+		t.Errorf("sortie :\n%s\nattendu :\n%s", out.String(), want)
+	}
+
+	if len(model.requests) != 3 {
+		t.Fatalf("%d requêtes au LLM, attendu 3", len(model.requests))
+	}
+	if last := model.requests[1].Messages[len(model.requests[1].Messages)-1]; last.ToolCallID != "call_1" || last.Content != `"`+long+`"` {
+		t.Errorf("résultat de l'outil renvoyé au modèle : %+v", last)
+	}
+	msgs := o.Conversation().Messages()
+	if len(msgs) != 6 || msgs[4].Content != "Merci" || msgs[5].Content != "De rien." {
+		t.Errorf("historique : %+v", msgs)
+	}
+}
+
+func TestChatEmptyInput(t *testing.T) {
+	var out strings.Builder
+	model := &scriptedLLM{}
+	o := newTestChat(t, "", &out, model, actions.NewRegistry())
+	runChatUntilEOF(t, o)
+	if out.String() != "Vous > \n" || len(model.requests) != 0 {
+		t.Errorf("sortie %q, %d requête(s) au LLM", out.String(), len(model.requests))
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"", 3, ""},
+		{"abc", 3, "abc"},
+		{"abcd", 3, "abc…"},
+		{"éèêë", 2, "éè…"},
+		{"日本語テキスト", 3, "日本語…"},
+	}
+	for _, tt := range tests {
+		if got := truncateRunes(tt.s, tt.n); got != tt.want {
+			t.Errorf("truncateRunes(%q, %d) = %q, attendu %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package orchestrator
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"tars/history"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Conversation garde l'historique des échanges entre les tours de parole.
+// Elle est partagée entre les étapes du pipeline, d'où le mutex.
+// Avec un store, chaque ajout ou modification est aussi enregistré sur disque.
+type Conversation struct {
+	mu       sync.Mutex
+	messages []openai.ChatCompletionMessage
+
+	store   *history.Store // nil : historique en mémoire seulement
+	session string
+
+	summary       string // Résumé des summaryCovers premiers messages (voir llm.ContextWindow)
+	summaryCovers int
+}
+
+func NewConversation() *Conversation {
+	return &Conversation{}
+}
+
+// NewStoredConversation reprend la session du store, messages déjà enregistrés compris.
+func NewStoredConversation(store *history.Store, session string) (*Conversation, error) {
+	entries, err := store.Entries(session)
+	if err != nil {
+		return nil, fmt.Errorf("chargement de l'historique: %w", err)
+	}
+	c := &Conversation{store: store, session: session}
+	for _, e := range entries {
+		c.messages = append(c.messages, e.Message)
+	}
+	if err := c.dropUnpairedToolCalls(); err != nil {
+		return nil, fmt.Errorf("réparation de l'historique: %w", err)
+	}
+	sess, err := store.Session(session) // Après la réparation, qui peut invalider le résumé
+	if err != nil {
+		return nil, fmt.Errorf("chargement de l'historique: %w", err)
+	}
+	c.summary, c.summaryCovers = sess.Summary, sess.SummaryCovers
+	return c, nil
+}
+
+// dropUnpairedToolCalls retire un aller-retour d'outils inachevé en fin d'historique (session
+// interrompue avant l'enregistrement des résultats, ou enregistrée par une ancienne version) :
+// le modèle refuse un message portant des tool calls sans un résultat pour chacun. Le texte
+// déjà prononcé avec les tool calls est conservé.
+func (c *Conversation) dropUnpairedToolCalls() error {
+	last := -1
+	for i := len(c.messages) - 1; i >= 0; i-- {
+		if len(c.messages[i].ToolCalls) > 0 {
+			last = i
+			break
+		}
+	}
+	if last < 0 {
+		return nil
+	}
+	answered := map[string]bool{}
+	for _, msg := range c.messages[last+1:] {
+		if msg.Role == openai.ChatMessageRoleTool {
+			answered[msg.ToolCallID] = true
+		}
+	}
+	for _, call := range c.messages[last].ToolCalls {
+		if !answered[call.ID] {
+			return c.truncateToolRound(last)
+		}
+	}
+	return nil
+}
+
+// truncateToolRound retire de l'historique le message i et ce qui le suit, sauf le texte du message i.
+func (c *Conversation) truncateToolRound(i int) error {
+	log.Printf("TARS Conversation: Tool calls sans résultat en fin de session %s, aller-retour retiré.", c.session)
+	keep := i
+	if c.messages[i].Content != "" {
+		c.messages[i].ToolCalls = nil
+		keep++
+	}
+	c.messages = c.messages[:keep]
+	if err := c.store.Truncate(c.session, keep); err != nil {
+		return err
+	}
+	if keep > i {
+		return c.store.Put(c.session, i, c.messages[i])
+	}
+	return nil
+}
+
+// Session retourne l'ID de la session enregistrée, "" si l'historique n'est pas persistant.
+func (c *Conversation) Session() string {
+	return c.session
+}
+
+// persist enregistre les messages des positions from à to (exclue) en une transaction.
+// Appelé avec c.mu verrouillé, pour que les écritures suivent l'ordre des modifications.
+// Un échec est journalisé sans interrompre la conversation, qui continue en mémoire.
+func (c *Conversation) persist(from, to int) {
+	if c.store == nil || from >= to {
+		return
+	}
+	if err := c.store.Put(c.session, from, c.messages[from:to]...); err != nil {
+		log.Printf("TARS Conversation: Erreur d'enregistrement de l'historique: %v", err)
+	}
+}
+
+// Append ajoute des messages à la fin de l'historique.
+func (c *Conversation) Append(msgs ...openai.ChatCompletionMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	from := len(c.messages)
+	c.messages = append(c.messages, msgs...)
+	c.persist(from, len(c.messages))
+}
+
+// AddUser ajoute une transcription de l'utilisateur.
+func (c *Conversation) AddUser(text string) {
+	c.Append(openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: text})
+}
+
+// AddAssistant ajoute une réponse du bot.
+func (c *Conversation) AddAssistant(text string) {
+	c.Append(openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: text})
+}
+
+// ReplaceLastAssistant remplace le dernier message du bot s'il vaut old
+// (par exemple pour ne garder que la partie entendue avant une interruption).
+func (c *Conversation) ReplaceLastAssistant(old, new string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := len(c.messages) - 1; i >= 0; i-- {
+		if c.messages[i].Role != openai.ChatMessageRoleAssistant {
+			continue
+		}
+		if c.messages[i].Content != old {
+			return false
+		}
+		c.messages[i].Content = new
+		c.persist(i, i+1)
+		return true
+	}
+	return false
+}
+
+// AppendToolRound ajoute un aller-retour d'outils : le message du modèle portant les tool calls,
+// qui remplace le dernier message du bot s'il a pour contenu spoken (le texte déjà prononcé
+// avant les tool calls), puis les résultats. Le tout est enregistré en une seule transaction,
+// pour qu'une session reprise n'ait jamais de tool calls sans résultats.
+func (c *Conversation) AppendToolRound(spoken string, assistant openai.ChatCompletionMessage, results []openai.ChatCompletionMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	from := len(c.messages)
+	if spoken != "" {
+		for i := len(c.messages) - 1; i >= 0; i-- {
+			if c.messages[i].Role != openai.ChatMessageRoleAssistant {
+				continue
+			}
+			if c.messages[i].Content == spoken {
+				c.messages[i] = assistant
+				from = i
+			}
+			break
+		}
+	}
+	if from == len(c.messages) {
+		c.messages = append(c.messages, assistant)
+	}
+	c.messages = append(c.messages, results...)
+	c.persist(from, len(c.messages))
+}
+
+// Summary retourne le résumé des échanges anciens et le nombre de messages qu'il couvre
+// ("" si la conversation n'a jamais été résumée).
+func (c *Conversation) Summary() (string, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.summary, c.summaryCovers
+}
+
+// SetSummary remplace le résumé des covered premiers messages, et l'enregistre avec la session.
+func (c *Conversation) SetSummary(summary string, covered int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.summary, c.summaryCovers = summary, covered
+	if c.store == nil {
+		return
+	}
+	if err := c.store.SetSummary(c.session, summary, covered); err != nil {
+		log.Printf("TARS Conversation: Erreur d'enregistrement du résumé: %v", err)
+	}
+}
+
+// Messages retourne une copie de l'historique, à passer au LLM.
+func (c *Conversation) Messages() []openai.ChatCompletionMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]openai.ChatCompletionMessage, len(c.messages))
+	copy(out, c.messages)
+	return out
+}
+
+// Len retourne le nombre de messages dans l'historique.
+func (c *Conversation) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.messages)
+}
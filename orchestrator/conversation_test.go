@@ -0,0 +1,149 @@
+package orchestrator
+
+import (
+	"path/filepath"
+	"testing"
+
+	"tars/history"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func openTestStore(t *testing.T) (*history.Store, string) {
+	t.Helper()
+	store, err := history.Open(filepath.Join(t.TempDir(), "tars.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	session, err := store.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, session
+}
+
+func toolCall(id string) openai.ToolCall {
+	return openai.ToolCall{ID: id, Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "heure", Arguments: "{}"}}
+}
+
+func toolResult(id string) openai.ChatCompletionMessage {
+	return openai.ChatCompletionMessage{Role: openai.ChatMessageRoleTool, ToolCallID: id, Content: `"15h04"`}
+}
+
+func TestConversationToolRoundPersisted(t *testing.T) {
+	store, session := openTestStore(t)
+	conv, err := NewStoredConversation(store, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conv.AddUser("Quelle heure est-il ?")
+	conv.AddAssistant("Je regarde.")
+	assistant := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: "Je regarde.", ToolCalls: []openai.ToolCall{toolCall("a"), toolCall("b")}}
+	conv.AppendToolRound("Je regarde.", assistant, []openai.ChatCompletionMessage{toolResult("a"), toolResult("b")})
+	conv.AddAssistant("Il est 15h04.")
+
+	resumed, err := NewStoredConversation(store, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs := resumed.Messages()
+	if len(msgs) != 5 {
+		t.Fatalf("%d messages repris, attendu 5 : %+v", len(msgs), msgs)
+	}
+	if len(msgs[1].ToolCalls) != 2 || msgs[1].Content != "Je regarde." {
+		t.Errorf("le texte prononcé n'a pas été remplacé par les tool calls : %+v", msgs[1])
+	}
+	if msgs[2].ToolCallID != "a" || msgs[3].ToolCallID != "b" {
+		t.Errorf("résultats : %+v", msgs[2:4])
+	}
+}
+
+func TestConversationDropsUnpairedToolCalls(t *testing.T) {
+	tests := []struct {
+		name string
+		tail []openai.ChatCompletionMessage // Fin de session telle qu'enregistrée avant l'interruption
+		want []openai.ChatCompletionMessage
+	}{
+		{
+			name: "sans résultat",
+			tail: []openai.ChatCompletionMessage{
+				{Role: openai.ChatMessageRoleAssistant, ToolCalls: []openai.ToolCall{toolCall("a")}},
+			},
+		},
+		{
+			name: "résultat partiel",
+			tail: []openai.ChatCompletionMessage{
+				{Role: openai.ChatMessageRoleAssistant, ToolCalls: []openai.ToolCall{toolCall("a"), toolCall("b")}},
+				toolResult("a"),
+			},
+		},
+		{
+			name: "texte prononcé conservé",
+			tail: []openai.ChatCompletionMessage{
+				{Role: openai.ChatMessageRoleAssistant, Content: "Je regarde.", ToolCalls: []openai.ToolCall{toolCall("a")}},
+			},
+			want: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleAssistant, Content: "Je regarde."}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, session := openTestStore(t)
+			user := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: "Quelle heure est-il ?"}
+			if err := store.Put(session, 0, append([]openai.ChatCompletionMessage{user}, tt.tail...)...); err != nil {
+				t.Fatal(err)
+			}
+
+			conv, err := NewStoredConversation(store, session)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := append([]openai.ChatCompletionMessage{user}, tt.want...)
+			if got := conv.Messages(); len(got) != len(want) || got[len(got)-1].Content != want[len(want)-1].Content || len(got[len(got)-1].ToolCalls) > 0 {
+				t.Fatalf("historique réparé : %+v, attendu %+v", got, want)
+			}
+
+			// La réparation est enregistrée : la suite de la conversation s'écrit à la bonne position.
+			conv.AddAssistant("Il est 15h04.")
+			entries, err := store.Entries(session)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != len(want)+1 || entries[len(entries)-1].Message.Content != "Il est 15h04." {
+				t.Errorf("%d messages enregistrés après réparation : %+v", len(entries), entries)
+			}
+		})
+	}
+}
+
+func TestConversationSummaryPersisted(t *testing.T) {
+	store, session := openTestStore(t)
+	conv, err := NewStoredConversation(store, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conv.AddUser("Je m'appelle Cooper.")
+	conv.AddAssistant("Enchanté, Cooper.")
+	conv.AddUser("Quelle heure est-il ?")
+	conv.SetSummary("L'utilisateur s'appelle Cooper.", 2)
+
+	resumed, err := NewStoredConversation(store, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary, covered := resumed.Summary(); summary != "L'utilisateur s'appelle Cooper." || covered != 2 {
+		t.Errorf("résumé repris %q couvrant %d messages", summary, covered)
+	}
+
+	// Un historique raccourci sous la partie résumée invalide le résumé.
+	if err := store.Truncate(session, 1); err != nil {
+		t.Fatal(err)
+	}
+	resumed, err = NewStoredConversation(store, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary, covered := resumed.Summary(); summary != "" || covered != 0 {
+		t.Errorf("résumé %q couvrant %d messages après troncature", summary, covered)
+	}
+}
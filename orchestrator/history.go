@@ -0,0 +1,60 @@
+package orchestrator
+
+import (
+	"fmt"
+	"log"
+
+	"tars/config"
+	"tars/history"
+)
+
+// openConversation ouvre l'historique persistant (config.HistoryPath) sur la session choisie par
+// config.HistorySession. Sans HistoryPath, l'historique reste en mémoire et le store est nil.
+func openConversation() (*Conversation, *history.Store, error) {
+	if config.HistoryPath == "" {
+		return NewConversation(), nil, nil
+	}
+	store, err := history.Open(config.HistoryPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session, err := pickSession(store, config.HistorySession)
+	if err != nil {
+		store.Close()
+		return nil, nil, err
+	}
+	conv, err := NewStoredConversation(store, session)
+	if err != nil {
+		store.Close()
+		return nil, nil, err
+	}
+	if conv.Len() > 0 {
+		log.Printf("TARS Orchestrator: Reprise de la session %s (%d messages).", session, conv.Len())
+	} else {
+		log.Printf("TARS Orchestrator: Nouvelle session %s.", session)
+	}
+	return conv, store, nil
+}
+
+// pickSession résout "last" (la plus récente, ou une nouvelle si la base est vide), "new" ou un ID.
+func pickSession(store *history.Store, choice string) (string, error) {
+	switch choice {
+	case "", "last":
+		last, ok, err := store.LastSession()
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return last.ID, nil
+		}
+		return store.NewSession()
+	case "new":
+		return store.NewSession()
+	default:
+		if _, err := store.Session(choice); err != nil {
+			return "", fmt.Errorf("session %q: %w", choice, err)
+		}
+		return choice, nil
+	}
+}
@@ -0,0 +1,462 @@
+// Package orchestrator relie les modules du pipeline vocal :
+// capture → VAD/segmentation → STT → LLM → TTS → player.
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"tars/actions"
+	_ "tars/actions/executors" // Enregistre les outils dans actions.DefaultRegistry
+	"tars/audio"
+	"tars/audio/convert"
+	"tars/config"
+	"tars/history"
+	"tars/llm"
+	"tars/persona"
+	"tars/stt"
+	"tars/tts"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Orchestrator possède tous les canaux et fait tourner chaque étape dans une goroutine supervisée.
+// portaudio.Initialize() doit avoir été appelé avant Run.
+type Orchestrator struct {
+	client *openai.Client
+
+	source    audio.AudioSource
+	vad       *audio.VAD
+	segmenter *audio.Segmenter
+	stt       *stt.STTProcessor
+	router    *actions.ActionRouter
+	llm       *llm.LLMProcessor
+	persona   *persona.Persona   // Prompt système, rendu à chaque tour (date et heure)
+	window    *llm.ContextWindow // Nil : historique complet envoyé au LLM
+	tts       *tts.TTSProcessor
+	player    audio.AudioSink // Nil en mode texte sans voix
+	chat      *chatIO         // Mode texte (tars chat) : remplace source, VAD et STT
+
+	// --- Canaux de communication ---
+	frameChan       chan []int16         // capture → segmenter
+	utteranceChan   chan audio.Utterance // segmenter → STT
+	transcriptChan  chan string          // STT → LLM
+	replyChan       chan reply           // LLM → TTS, réponses rattachées à leur tour
+	pcmChan         chan convert.Chunk   // TTS → player
+	speechStartChan chan time.Time       // segmenter → barge-in
+
+	conversation *Conversation
+	store        *history.Store // Historique persistant, nil s'il est désactivé
+
+	turnMu sync.Mutex
+	turn   *turn // Réponse en cours, annulée si l'utilisateur reprend la parole
+
+	wg      sync.WaitGroup
+	cancel  context.CancelFunc
+	errOnce sync.Once
+	err     error
+}
+
+// New instancie tous les modules. Rien ne démarre avant Run.
+func New(client *openai.Client) (*Orchestrator, error) {
+	o := newOrchestrator(client)
+	if err := o.initConversation(); err != nil {
+		return nil, err
+	}
+	if err := o.initListening(); err != nil {
+		return nil, err
+	}
+	if err := o.initSpeech(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func newOrchestrator(client *openai.Client) *Orchestrator {
+	return &Orchestrator{
+		client:          client,
+		frameChan:       make(chan []int16, 50),
+		utteranceChan:   make(chan audio.Utterance, 4),
+		transcriptChan:  make(chan string, 4),
+		replyChan:       make(chan reply, 4),
+		pcmChan:         make(chan convert.Chunk, 32),
+		speechStartChan: make(chan time.Time, 1),
+	}
+}
+
+// initConversation crée ce qui est commun à tous les modes : historique, outils, LLM,
+// persona et fenêtre de contexte.
+func (o *Orchestrator) initConversation() error {
+	var err error
+	o.conversation, o.store, err = openConversation()
+	if err != nil {
+		return fmt.Errorf("historique: %w", err)
+	}
+
+	o.router = actions.NewActionRouter(actions.DefaultRegistry)
+	log.Printf("TARS Orchestrator: %d outil(s) disponible(s) pour le LLM.", o.router.Registry().Len())
+	model, err := llm.New(o.client)
+	if err != nil {
+		return fmt.Errorf("création LLM: %w", err)
+	}
+	log.Printf("TARS Orchestrator: LLM %s.", model.Name())
+	o.llm = llm.NewLLMProcessor(model, o.router, nil)
+	o.persona, err = persona.New(o.router.Registry())
+	if err != nil {
+		return fmt.Errorf("création persona: %w", err)
+	}
+	if config.LLMContextBudget > 0 {
+		tokenizer, err := llm.NewTokenizer(config.LLMModel)
+		if err != nil {
+			return fmt.Errorf("création tokenizer: %w", err)
+		}
+		o.window = llm.NewContextWindow(model, tokenizer, config.LLMContextBudget, config.LLMContextKeepTurns,
+			llm.ToolsFromRegistry(o.router.Registry()))
+		// Le résumé est enregistré avec la conversation : une session reprise n'est pas résumée à nouveau.
+		o.window.Restore(o.conversation.Summary())
+		o.window.OnSummary(o.conversation.SetSummary)
+	}
+	return nil
+}
+
+// initListening crée l'entrée vocale : source audio, VAD, segmentation et STT.
+func (o *Orchestrator) initListening() error {
+	var err error
+	o.source, err = NewSource(o.frameChan)
+	if err != nil {
+		return fmt.Errorf("création source audio: %w", err)
+	}
+	o.segmenter, o.vad, err = NewSegmenter(o.frameChan, o.utteranceChan)
+	if err != nil {
+		return err
+	}
+	if config.BargeInEnabled {
+		o.segmenter.NotifySpeechStart(o.speechStartChan)
+	}
+
+	sttEngine, err := stt.New(o.client)
+	if err != nil {
+		return fmt.Errorf("création STT: %w", err)
+	}
+	o.stt = stt.NewSTTProcessor(sttEngine, stt.Format{
+		SampleRate: config.SampleRate,
+		Channels:   config.Channels,
+		BitDepth:   config.BitDepth,
+	}, o.transcriptChan)
+	return nil
+}
+
+// initSpeech crée la sortie vocale : TTS et player.
+func (o *Orchestrator) initSpeech() error {
+	ttsEngine, err := tts.New(o.client)
+	if err != nil {
+		return fmt.Errorf("création TTS: %w", err)
+	}
+	log.Printf("TARS Orchestrator: TTS %s.", ttsEngine.Name())
+	o.tts = tts.NewTTSProcessor(ttsEngine, o.pcmChan)
+
+	o.player, err = NewSink(o.pcmChan)
+	if err != nil {
+		return fmt.Errorf("création sortie audio: %w", err)
+	}
+	return nil
+}
+
+// Conversation retourne l'historique partagé.
+func (o *Orchestrator) Conversation() *Conversation {
+	return o.conversation
+}
+
+// Run démarre toutes les étapes et bloque jusqu'à l'annulation du contexte
+// (ou une erreur fatale d'une étape), puis arrête tout proprement.
+func (o *Orchestrator) Run(ctx context.Context) error {
+	ctx, o.cancel = context.WithCancel(ctx)
+	defer o.cancel()
+
+	if o.player != nil {
+		go o.player.StartPlaybackLoop()
+	}
+
+	if o.chat != nil {
+		o.supervise(ctx, "chat", false, o.runChat)
+	} else {
+		o.supervise(ctx, "capture", false, o.runSource)
+		o.supervise(ctx, "segmenter", false, o.runSegmenter)
+		o.supervise(ctx, "stt", true, o.runSTT)
+		o.supervise(ctx, "llm", true, o.runLLM)
+		o.supervise(ctx, "barge-in", true, o.runBargeIn)
+	}
+	o.supervise(ctx, "tts", true, o.runTTS)
+
+	log.Println("TARS Orchestrator: Pipeline démarré.")
+	<-ctx.Done()
+
+	log.Println("TARS Orchestrator: Arrêt du pipeline...")
+	o.wg.Wait() // Plus aucune étape n'écrit sur pcmChan après ce point
+	if o.player != nil {
+		o.player.Close()
+	}
+	if o.vad != nil {
+		o.vad.Close()
+	}
+	if o.store != nil {
+		if err := o.store.Close(); err != nil {
+			log.Printf("TARS Orchestrator: Erreur fermeture historique: %v", err)
+		}
+	}
+	log.Println("TARS Orchestrator: Pipeline arrêté.")
+	return o.err
+}
+
+// runSource fait tourner la source audio. Une source finie (fichier, stdin) ferme frameChan
+// à la fin de son flux : la fin se propage d'étape en étape (segmenter, STT, LLM) et le
+// pipeline s'arrête une fois la dernière réponse prononcée (voir finish).
+func (o *Orchestrator) runSource(ctx context.Context) {
+	if err := o.source.Start(ctx); err != nil {
+		o.fail(fmt.Errorf("source audio: %w", err))
+		return
+	}
+	<-ctx.Done()
+}
+
+// runSegmenter découpe les frames en énoncés jusqu'à la fin de la source (ou l'arrêt).
+func (o *Orchestrator) runSegmenter(ctx context.Context) {
+	o.segmenter.Start(ctx)
+	<-ctx.Done()
+}
+
+// runSTT envoie chaque énoncé détecté au STT.
+func (o *Orchestrator) runSTT(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case u, ok := <-o.utteranceChan:
+			if !ok {
+				// Plus d'énoncé : toutes les transcriptions ont été envoyées.
+				close(o.transcriptChan)
+				<-ctx.Done()
+				return
+			}
+			log.Printf("TARS Orchestrator: Énoncé de %v reçu, transcription...", u.Duration())
+			o.stt.Process(ctx, convert.Int16ToBytes(u.PCM))
+		}
+	}
+}
+
+// reply est une réponse du LLM rattachée au tour qui l'a demandée. events livre,
+// dans l'ordre, le texte à prononcer et les allers-retours d'outils à enregistrer.
+type reply struct {
+	turn   *turn
+	events <-chan llm.AgentEvent
+}
+
+// startTurn ouvre un nouveau tour de réponse. Le précédent, s'il parle encore (ou attend d'être
+// joué), est interrompu comme par un barge-in : son audio est coupé et l'historique tronqué.
+func (o *Orchestrator) startTurn(ctx context.Context) *turn {
+	o.interrupt()
+	o.turnMu.Lock()
+	defer o.turnMu.Unlock()
+	o.turn = newTurn(ctx)
+	return o.turn
+}
+
+func (o *Orchestrator) currentTurn() *turn {
+	o.turnMu.Lock()
+	defer o.turnMu.Unlock()
+	return o.turn
+}
+
+// runLLM ajoute chaque transcription à l'historique et interroge le LLM.
+func (o *Orchestrator) runLLM(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case text, ok := <-o.transcriptChan:
+			if !ok {
+				o.finish(ctx)
+				return
+			}
+			text = strings.TrimSpace(text)
+			if text == "" {
+				log.Println("TARS Orchestrator: Transcription vide, ignorée.")
+				continue
+			}
+			t := o.startTurn(ctx) // Avant AddUser : la réponse interrompue est tronquée à sa place
+			o.conversation.AddUser(text)
+			o.ask(ctx, t)
+		}
+	}
+}
+
+// finish arrête le pipeline quand la source est épuisée : la dernière réponse a été
+// demandée (ask est synchrone), on attend qu'elle soit prononcée puis jouée.
+func (o *Orchestrator) finish(ctx context.Context) {
+	log.Println("TARS Orchestrator: Source audio terminée, fin de la dernière réponse...")
+	if !o.waitIdle(ctx) {
+		return
+	}
+	log.Println("TARS Orchestrator: Conversation terminée.")
+	o.cancel()
+}
+
+// waitIdle attend que la réponse en cours soit entièrement synthétisée puis jouée.
+// Retourne false si ctx est annulé avant.
+func (o *Orchestrator) waitIdle(ctx context.Context) bool {
+	if t := o.currentTurn(); t != nil {
+		select {
+		case <-t.done:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	for o.player != nil && o.player.IsPlaying() {
+		select {
+		case <-time.After(50 * time.Millisecond):
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// ask fait tourner la boucle d'agent du LLM (outils compris) pour le tour t,
+// dont le TTS consomme les événements au fil de l'eau. L'historique est d'abord
+// ramené au budget de tokens (résumé des échanges anciens) par la fenêtre de contexte.
+func (o *Orchestrator) ask(ctx context.Context, t *turn) {
+	events := make(chan llm.AgentEvent, 16)
+	select {
+	case o.replyChan <- reply{turn: t, events: events}:
+	case <-ctx.Done():
+		return
+	}
+
+	messages := o.window.Fit(t.ctx, o.messages())
+	resp := o.llm.RunAgent(t.ctx, messages, config.LLMStreaming, events)
+	if resp.Error != nil && t.ctx.Err() == nil { // Annulé : l'erreur vient de l'interruption
+		log.Printf("TARS Orchestrator: Erreur LLM: %v", resp.Error)
+		if o.chat != nil {
+			o.chat.error(resp.Error)
+		}
+	}
+}
+
+// messages retourne la requête du tour : le prompt système, rendu maintenant, puis l'historique.
+// Le prompt n'est pas enregistré dans la conversation : il change avec l'heure et la config.
+func (o *Orchestrator) messages() []openai.ChatCompletionMessage {
+	history := o.conversation.Messages()
+	system, err := o.persona.Message(time.Now())
+	if err != nil {
+		log.Printf("TARS Orchestrator: %v, requête sans prompt système.", err)
+		return history
+	}
+	return append([]openai.ChatCompletionMessage{system}, history...)
+}
+
+// runTTS enregistre la réponse du LLM dans l'historique et la fait synthétiser.
+func (o *Orchestrator) runTTS(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case r := <-o.replyChan:
+			o.speak(r)
+		}
+	}
+}
+
+// speak synthétise la réponse au fil des événements du LLM en tenant l'historique à jour.
+func (o *Orchestrator) speak(r reply) {
+	t := r.turn
+	defer t.finishSpeaking()
+	if o.chat != nil {
+		defer o.chat.endReply()
+	}
+
+	for ev := range r.events {
+		if t.ctx.Err() != nil {
+			return // Interrompu : le LLM s'arrête de lui-même, inutile de vider le canal
+		}
+		if ev.ToolRound != nil {
+			o.recordToolRound(t, ev.ToolRound)
+			continue
+		}
+		o.say(t, ev.Text)
+	}
+}
+
+// recordToolRound enregistre dans l'historique le message du modèle portant les tool calls
+// (à la place du texte déjà prononcé pour ce message) suivi des résultats des outils.
+func (o *Orchestrator) recordToolRound(t *turn, round *llm.ToolRound) {
+	if o.chat != nil {
+		o.chat.toolRound(round)
+	}
+	o.conversation.AppendToolRound(t.resetReply(), round.Assistant, round.Results)
+}
+
+// say ajoute text à la réponse du tour dans l'historique puis le fait synthétiser
+// (et l'affiche en mode texte).
+func (o *Orchestrator) say(t *turn, text string) {
+	prev, next := t.appendReply(text)
+	if prev == "" {
+		o.conversation.AddAssistant(next)
+	} else {
+		o.conversation.ReplaceLastAssistant(prev, next)
+	}
+	if o.chat != nil {
+		o.chat.reply(text)
+	}
+	if o.tts == nil {
+		return
+	}
+	t.addSegment(text, o.tts.Process(t.ctx, text))
+}
+
+// runBargeIn interrompt le bot dès que l'utilisateur commence à parler.
+func (o *Orchestrator) runBargeIn(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-o.speechStartChan:
+			o.interrupt()
+		}
+	}
+}
+
+// interrupt annule la réponse en cours (requêtes LLM et TTS), coupe le player et
+// tronque l'historique à ce que l'utilisateur a réellement entendu.
+func (o *Orchestrator) interrupt() {
+	t := o.currentTurn()
+	if t != nil {
+		t.cancel()
+		// Le TTS doit avoir cessé d'envoyer du PCM avant qu'on vide le player.
+		t.waitSpeaking(50 * time.Millisecond)
+	}
+	if o.player == nil || !o.player.IsPlaying() {
+		return // Rien en cours de lecture : ce qui a été dit a été entendu
+	}
+
+	dropped := o.player.Interrupt()
+	if t == nil {
+		return
+	}
+	replyText, heard, heardDur, sent := t.heard(dropped)
+	if replyText == "" || sent == 0 {
+		return
+	}
+
+	truncated := "[interrompu par l'utilisateur avant la réponse]"
+	if heard != "" {
+		truncated = heard + "… [interrompu par l'utilisateur]"
+	}
+	if o.conversation.ReplaceLastAssistant(replyText, truncated) {
+		log.Printf("TARS Orchestrator: Barge-in, %v entendus sur %v. Historique tronqué: %q",
+			heardDur.Round(time.Millisecond), sent.Round(time.Millisecond), truncated)
+	}
+}
@@ -0,0 +1,70 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"time"
+)
+
+const (
+	restartBackoffMin = 500 * time.Millisecond
+	restartBackoffMax = 10 * time.Second
+)
+
+// supervise lance une étape du pipeline dans sa propre goroutine.
+// Une étape redémarrable est relancée (avec backoff) si elle panique ; les autres
+// (capture, segmentation, qui ferment leurs canaux de sortie) arrêtent tout le pipeline.
+// Une étape qui se termine d'elle-même alors que le contexte est actif arrête aussi le pipeline.
+func (o *Orchestrator) supervise(ctx context.Context, name string, restartable bool, stage func(ctx context.Context)) {
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+
+		backoff := restartBackoffMin
+		for {
+			err := runStage(ctx, stage)
+			if ctx.Err() != nil {
+				log.Printf("TARS Orchestrator: Étape '%s' arrêtée.", name)
+				return
+			}
+			if err == nil {
+				o.fail(fmt.Errorf("étape '%s' terminée de façon inattendue", name))
+				return
+			}
+			if !restartable {
+				o.fail(fmt.Errorf("étape '%s': %w", name, err))
+				return
+			}
+
+			log.Printf("TARS Orchestrator: Étape '%s' en échec (%v), redémarrage dans %v.", name, err, backoff)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, restartBackoffMax)
+		}
+	}()
+}
+
+// runStage exécute une étape en convertissant une panique en erreur.
+func runStage(ctx context.Context, stage func(ctx context.Context)) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panique: %v\n%s", r, debug.Stack())
+		}
+	}()
+	stage(ctx)
+	return nil
+}
+
+// fail enregistre la première erreur fatale et arrête le pipeline.
+func (o *Orchestrator) fail(err error) {
+	o.errOnce.Do(func() {
+		log.Printf("TARS Orchestrator: Erreur fatale: %v", err)
+		o.err = err
+		o.cancel()
+	})
+}
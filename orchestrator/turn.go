@@ -0,0 +1,136 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// spokenSegment est un morceau de réponse envoyé au TTS et la durée d'audio qu'il a produite.
+type spokenSegment struct {
+	text     string
+	duration time.Duration
+}
+
+// turn représente une réponse du bot en cours (LLM puis TTS), annulable par une interruption.
+type turn struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu         sync.Mutex
+	reply      string          // Texte de la réponse tel qu'enregistré dans l'historique
+	replyStart int             // Index dans segments du premier morceau de reply
+	segments   []spokenSegment // Morceaux synthétisés, dans l'ordre de lecture
+	speaking   bool            // Le TTS a commencé pour cette réponse
+	done       chan struct{}   // Fermé quand le TTS a fini (ou abandonné) d'envoyer du PCM
+}
+
+func newTurn(parent context.Context) *turn {
+	ctx, cancel := context.WithCancel(parent)
+	return &turn{ctx: ctx, cancel: cancel, done: make(chan struct{})}
+}
+
+// appendReply ajoute un morceau au texte de la réponse et retourne l'ancien et le nouveau texte.
+func (t *turn) appendReply(text string) (prev, next string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	prev = t.reply
+	if t.reply == "" {
+		t.reply = text
+	} else {
+		t.reply += " " + text
+	}
+	t.speaking = true
+	return prev, t.reply
+}
+
+// resetReply clôt le message en cours (par exemple quand il porte des tool calls) :
+// les morceaux suivants formeront un nouveau message dans l'historique.
+// Retourne le texte du message clos.
+func (t *turn) resetReply() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	prev := t.reply
+	t.reply = ""
+	t.replyStart = len(t.segments)
+	return prev
+}
+
+// addSegment enregistre la durée d'audio produite pour un morceau.
+func (t *turn) addSegment(text string, duration time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.segments = append(t.segments, spokenSegment{text: text, duration: duration})
+}
+
+// finishSpeaking libère ceux qui attendent la fin du TTS.
+func (t *turn) finishSpeaking() {
+	close(t.done)
+}
+
+// waitSpeaking attend (au plus timeout) que le TTS ait rendu la main, s'il a commencé.
+func (t *turn) waitSpeaking(timeout time.Duration) {
+	t.mu.Lock()
+	speaking := t.speaking
+	t.mu.Unlock()
+	if !speaking {
+		return
+	}
+	select {
+	case <-t.done:
+	case <-time.After(timeout):
+	}
+}
+
+// heard retourne le texte de la réponse (tel que dans l'historique), la partie réellement
+// entendue sachant que dropped d'audio n'a pas été joué, et les durées correspondantes.
+// Seuls les morceaux du message en cours (depuis le dernier resetReply) sont comptés.
+func (t *turn) heard(dropped time.Duration) (reply, heard string, heardDur, total time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	segments := t.segments[t.replyStart:]
+	for _, seg := range segments {
+		total += seg.duration
+	}
+	heardDur = max(total-dropped, 0)
+
+	var parts []string
+	remaining := heardDur
+	for _, seg := range segments {
+		if remaining <= 0 {
+			break
+		}
+		if remaining >= seg.duration {
+			parts = append(parts, seg.text)
+			remaining -= seg.duration
+			continue
+		}
+		if p := heardPrefix(seg.text, remaining, seg.duration); p != "" {
+			parts = append(parts, p)
+		}
+		break
+	}
+	return t.reply, strings.Join(parts, " "), heardDur, total
+}
+
+// heardPrefix estime la partie de text réellement entendue, proportionnellement à l'audio
+// joué, coupée au dernier mot complet. Retourne "" si rien n'a été entendu.
+func heardPrefix(text string, heard, total time.Duration) string {
+	if heard <= 0 || total <= 0 {
+		return ""
+	}
+	if heard >= total {
+		return text
+	}
+
+	runes := []rune(text)
+	cut := int(int64(len(runes)) * int64(heard) / int64(total))
+	// Un mot coupé n'a pas été entendu : on recule jusqu'à l'espace qui le précède.
+	for cut > 0 && !unicode.IsSpace(runes[cut]) {
+		cut--
+	}
+	return strings.TrimSpace(string(runes[:cut]))
+}
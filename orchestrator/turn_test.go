@@ -0,0 +1,89 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHeardPrefix(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		heard, total time.Duration
+		want         string
+	}{
+		{"rien entendu", "Bonjour tout le monde", 0, 10, ""},
+		{"durée inconnue", "Bonjour tout le monde", 5, 0, ""},
+		{"tout entendu", "Bonjour tout le monde", 10, 10, "Bonjour tout le monde"},
+		{"plus que tout", "Bonjour tout le monde", 15, 10, "Bonjour tout le monde"},
+		{"mot coupé", "Bonjour tout le monde", 5, 10, "Bonjour"},
+		{"fin de mot", "Bonjour tout le monde", 12, 21, "Bonjour tout"},
+		{"premier mot inachevé", "Bonjour tout le monde", 3, 21, ""},
+		{"multi-octets", "Déjà été à Paris", 9, 16, "Déjà été"},
+		{"multi-octets mot coupé", "Déjà été à Paris", 12, 16, "Déjà été à"},
+		{"espace insécable", "Il coûte 15 euros", 13, 18, "Il coûte 15"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := heardPrefix(tt.text, tt.heard, tt.total); got != tt.want {
+				t.Errorf("heardPrefix(%q, %d, %d) = %q, attendu %q", tt.text, tt.heard, tt.total, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTurnHeard(t *testing.T) {
+	tr := newTurn(context.Background())
+	for _, seg := range []spokenSegment{
+		{"Bonjour à tous.", time.Second},
+		{"Il fait beau aujourd'hui.", 2 * time.Second},
+	} {
+		tr.appendReply(seg.text)
+		tr.addSegment(seg.text, seg.duration)
+	}
+
+	tests := []struct {
+		dropped  time.Duration
+		heard    string
+		heardDur time.Duration
+	}{
+		{0, "Bonjour à tous. Il fait beau aujourd'hui.", 3 * time.Second},
+		{time.Second, "Bonjour à tous. Il fait beau", 2 * time.Second},
+		{2 * time.Second, "Bonjour à tous.", time.Second},
+		{2500 * time.Millisecond, "Bonjour", 500 * time.Millisecond},
+		{2800 * time.Millisecond, "", 200 * time.Millisecond}, // Moins d'un mot du premier morceau
+		{3 * time.Second, "", 0},
+		{5 * time.Second, "", 0},
+	}
+	for _, tt := range tests {
+		reply, heard, heardDur, total := tr.heard(tt.dropped)
+		if reply != "Bonjour à tous. Il fait beau aujourd'hui." {
+			t.Errorf("jeté %v : réponse %q", tt.dropped, reply)
+		}
+		if heard != tt.heard || heardDur != tt.heardDur || total != 3*time.Second {
+			t.Errorf("jeté %v : entendu %q (%v sur %v), attendu %q (%v sur 3s)",
+				tt.dropped, heard, heardDur, total, tt.heard, tt.heardDur)
+		}
+	}
+}
+
+func TestTurnHeardAfterReset(t *testing.T) {
+	// Après des tool calls, seul le message en cours compte.
+	tr := newTurn(context.Background())
+	tr.appendReply("Je regarde.")
+	tr.addSegment("Je regarde.", time.Second)
+	if prev := tr.resetReply(); prev != "Je regarde." {
+		t.Fatalf("resetReply = %q", prev)
+	}
+	tr.appendReply("Il est quinze heures.")
+	tr.addSegment("Il est quinze heures.", 2*time.Second)
+
+	reply, heard, heardDur, total := tr.heard(time.Second)
+	if reply != "Il est quinze heures." || total != 2*time.Second {
+		t.Errorf("réponse %q de %v, attendu le seul message en cours", reply, total)
+	}
+	if heard != "Il est" || heardDur != time.Second {
+		t.Errorf("entendu %q (%v), attendu %q (1s)", heard, heardDur, "Il est")
+	}
+}
@@ -0,0 +1,66 @@
+package persona
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// language retourne le code de langue d'une locale : « fr-FR », « fr_FR.UTF-8 » → « fr ».
+func language(locale string) string {
+	lang, _, _ := strings.Cut(locale, ".")
+	lang, _, _ = strings.Cut(lang, "_")
+	lang, _, _ = strings.Cut(lang, "-")
+	return strings.ToLower(lang)
+}
+
+var languageNames = map[string]string{
+	"fr": "français",
+	"en": "anglais",
+	"es": "espagnol",
+	"de": "allemand",
+	"it": "italien",
+	"pt": "portugais",
+	"nl": "néerlandais",
+}
+
+// languageName retourne le nom de la langue lang, ou le code lui-même s'il est inconnu.
+func languageName(lang string) string {
+	if name, ok := languageNames[lang]; ok {
+		return name
+	}
+	if lang == "" {
+		return languageNames["fr"]
+	}
+	return lang
+}
+
+var (
+	frenchWeekdays = [...]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"}
+	frenchMonths   = [...]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet",
+		"août", "septembre", "octobre", "novembre", "décembre"}
+)
+
+// formatDate écrit la date en toutes lettres en français ou en anglais ; les autres
+// langues ont le format ISO, que les modèles comprennent sans ambiguïté.
+func formatDate(t time.Time, lang string) string {
+	switch lang {
+	case "fr", "":
+		day := fmt.Sprint(t.Day())
+		if t.Day() == 1 {
+			day = "1er"
+		}
+		return fmt.Sprintf("%s %s %s %d", frenchWeekdays[t.Weekday()], day, frenchMonths[t.Month()-1], t.Year())
+	case "en":
+		return t.Format("Monday, January 2, 2006")
+	default:
+		return t.Format("2006-01-02 (Monday)")
+	}
+}
+
+func formatTime(t time.Time, lang string) string {
+	if lang == "en" {
+		return t.Format("3:04 PM")
+	}
+	return t.Format("15:04")
+}
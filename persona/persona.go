@@ -0,0 +1,135 @@
+// Package persona construit le prompt système de TARS à partir d'un template (text/template) :
+// personnalité, langue, date et heure, nom de l'utilisateur, outils disponibles, réglages
+// d'humour et d'honnêteté, suivis de consignes fixes pour des réponses faites pour être dites.
+package persona
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"tars/actions"
+	"tars/config"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+//go:embed default.tmpl
+var defaultTemplate string
+
+// speechGuidance est ajoutée après le template, quel qu'il soit : les réponses sont lues
+// par le TTS, le markdown et les listes y seraient prononcés tels quels.
+const speechGuidance = `Tes réponses sont lues à voix haute par une synthèse vocale :
+- Sois bref : une à trois phrases, sauf si on te demande explicitement des détails.
+- Pas de markdown, de listes, de titres, d'emojis, de tableaux, de code ni d'URL : uniquement des phrases à dire.
+- Écris les nombres, unités et abréviations comme on les prononce quand c'est ambigu.
+- Si la demande est floue, pose une seule question courte.`
+
+// Tool décrit un outil pour le template.
+type Tool struct {
+	Name        string
+	Description string
+}
+
+// Data est passée au template à chaque requête.
+type Data struct {
+	Now      time.Time // Pour des formats personnalisés : {{.Now.Format "15:04"}}
+	Date     string    // Date en toutes lettres selon la locale, ex. « samedi 17 octobre 2026 »
+	Time     string    // Heure selon la locale, ex. « 15:04 »
+	Locale   string    // Telle que configurée, ex. « fr-FR »
+	Language string    // Nom de la langue de la locale, en français, ex. « français »
+	UserName string    // Vide si non configuré
+	Tools    []Tool
+	Humour   int // Pourcentage, comme les réglages de TARS dans le film
+	Honesty  int // Pourcentage
+}
+
+// Persona rend le prompt système. Elle est sûre pour un usage concurrent.
+type Persona struct {
+	tmpl     *template.Template
+	locale   string
+	userName string
+	humour   int
+	honesty  int
+	tools    []Tool
+}
+
+// New charge le template config.PromptTemplate (vide : template intégré) et les réglages
+// de la config ; les outils décrits sont ceux de registry (nil : aucun). Le template est
+// rendu une première fois pour signaler ses erreurs dès le démarrage.
+func New(registry *actions.Registry) (*Persona, error) {
+	text, name := defaultTemplate, "intégré"
+	if config.PromptTemplate != "" {
+		data, err := os.ReadFile(config.PromptTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("lecture du template de prompt: %w", err)
+		}
+		text, name = string(data), config.PromptTemplate
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("template de prompt %s: %w", name, err)
+	}
+
+	p := &Persona{
+		tmpl:     tmpl,
+		locale:   config.Locale,
+		userName: config.UserName,
+		humour:   clampPercent(config.HumourSetting),
+		honesty:  clampPercent(config.HonestySetting),
+	}
+	if registry != nil {
+		for _, e := range registry.Executors() {
+			p.tools = append(p.tools, Tool{Name: e.Name(), Description: e.Description()})
+		}
+	}
+	if _, err := p.SystemPrompt(time.Now()); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Data retourne les variables du template à l'instant now.
+func (p *Persona) Data(now time.Time) Data {
+	lang := language(p.locale)
+	return Data{
+		Now:      now,
+		Date:     formatDate(now, lang),
+		Time:     formatTime(now, lang),
+		Locale:   p.locale,
+		Language: languageName(lang),
+		UserName: p.userName,
+		Tools:    p.tools,
+		Humour:   p.humour,
+		Honesty:  p.honesty,
+	}
+}
+
+// SystemPrompt rend le template à l'instant now, suivi des consignes d'expression orale.
+func (p *Persona) SystemPrompt(now time.Time) (string, error) {
+	var b strings.Builder
+	if err := p.tmpl.Execute(&b, p.Data(now)); err != nil {
+		return "", fmt.Errorf("rendu du prompt: %w", err)
+	}
+	prompt := strings.TrimSpace(b.String())
+	if prompt != "" {
+		prompt += "\n\n"
+	}
+	return prompt + speechGuidance, nil
+}
+
+// Message retourne le prompt système à l'instant now, prêt à placer en tête de la conversation.
+func (p *Persona) Message(now time.Time) (openai.ChatCompletionMessage, error) {
+	prompt, err := p.SystemPrompt(now)
+	if err != nil {
+		return openai.ChatCompletionMessage{}, err
+	}
+	return openai.ChatCompletionMessage{Role: openai.ChatMessageRoleSystem, Content: prompt}, nil
+}
+
+func clampPercent(v int) int {
+	return min(max(v, 0), 100)
+}
@@ -0,0 +1,161 @@
+package persona
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"tars/actions"
+	"tars/config"
+)
+
+// now est un samedi après-midi.
+var now = time.Date(2026, time.October, 17, 15, 4, 0, 0, time.UTC)
+
+// setConfig remplace les réglages de la persona le temps du test.
+func setConfig(t *testing.T, tmpl, locale, userName string, humour, honesty int) {
+	t.Helper()
+	prevTmpl, prevLocale, prevUser := config.PromptTemplate, config.Locale, config.UserName
+	prevHumour, prevHonesty := config.HumourSetting, config.HonestySetting
+	t.Cleanup(func() {
+		config.PromptTemplate, config.Locale, config.UserName = prevTmpl, prevLocale, prevUser
+		config.HumourSetting, config.HonestySetting = prevHumour, prevHonesty
+	})
+	config.PromptTemplate, config.Locale, config.UserName = tmpl, locale, userName
+	config.HumourSetting, config.HonestySetting = humour, honesty
+}
+
+// writeTemplate écrit un template personnalisé et retourne son chemin.
+func writeTemplate(t *testing.T, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "prompt.tmpl")
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLocaleFormats(t *testing.T) {
+	first := time.Date(2026, time.November, 1, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		locale     string
+		at         time.Time
+		date, hour string
+		language   string
+	}{
+		{"fr-FR", now, "samedi 17 octobre 2026", "15:04", "français"},
+		{"fr_FR.UTF-8", first, "dimanche 1er novembre 2026", "09:30", "français"},
+		{"", now, "samedi 17 octobre 2026", "15:04", "français"},
+		{"en-US", now, "Saturday, October 17, 2026", "3:04 PM", "anglais"},
+		{"en_GB", first, "Sunday, November 1, 2026", "9:30 AM", "anglais"},
+		{"de-DE", now, "2026-10-17 (Saturday)", "15:04", "allemand"},
+		{"sv-SE", now, "2026-10-17 (Saturday)", "15:04", "sv"},
+	}
+	for _, tt := range tests {
+		lang := language(tt.locale)
+		if got := formatDate(tt.at, lang); got != tt.date {
+			t.Errorf("%q : date %q, attendu %q", tt.locale, got, tt.date)
+		}
+		if got := formatTime(tt.at, lang); got != tt.hour {
+			t.Errorf("%q : heure %q, attendu %q", tt.locale, got, tt.hour)
+		}
+		if got := languageName(lang); got != tt.language {
+			t.Errorf("%q : langue %q, attendu %q", tt.locale, got, tt.language)
+		}
+	}
+}
+
+func TestDefaultTemplate(t *testing.T) {
+	tmpl := template.Must(template.New("intégré").Option("missingkey=error").Parse(defaultTemplate))
+	data := Data{
+		Now: now, Date: "samedi 17 octobre 2026", Time: "15:04", Locale: "fr-FR", Language: "français",
+		UserName: "Cooper", Tools: []Tool{{Name: "meteo", Description: "Donne la météo."}}, Humour: 75, Honesty: 40,
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"l'assistant vocal de Cooper.",
+		"Nous sommes le samedi 17 octobre 2026, il est 15:04. Réponds en français",
+		"Réglages : humour 75 %, honnêteté 40 %. Tu as l'humour pince-sans-rire",
+		"Tu privilégies le tact à la franchise",
+		"- meteo : Donne la météo.",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("%q absent du prompt :\n%s", want, b.String())
+		}
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	// Réglages hors limites ramenés à 0..100, locale anglaise, outils du registry.
+	setConfig(t, "", "en-US", "", 150, -5)
+	registry := actions.NewRegistry()
+	ping := actions.NewExecutor("ping", "Répond pong.", func(context.Context, struct{}) (any, error) { return "pong", nil })
+	if err := registry.Register(ping); err != nil {
+		t.Fatal(err)
+	}
+	p, err := New(registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := p.Data(now); d.Humour != 100 || d.Honesty != 0 {
+		t.Errorf("humour %d, honnêteté %d, attendu 100 et 0", d.Humour, d.Honesty)
+	}
+	prompt, err := p.SystemPrompt(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"ton utilisateur",
+		"Saturday, October 17, 2026, il est 3:04 PM. Réponds en anglais",
+		"humour 100 %, honnêteté 0 %",
+		"- ping : Répond pong.",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("%q absent du prompt :\n%s", want, prompt)
+		}
+	}
+	if !strings.HasSuffix(prompt, "\n\n"+speechGuidance) {
+		t.Errorf("consignes orales absentes de la fin du prompt :\n%s", prompt)
+	}
+
+	msg, err := p.Message(now)
+	if err != nil || msg.Role != "system" || msg.Content != prompt {
+		t.Errorf("Message = %+v (%v)", msg, err)
+	}
+}
+
+func TestCustomTemplate(t *testing.T) {
+	tests := []struct {
+		name, text string
+		want       string // Prompt attendu, consignes orales comprises
+		wantErr    bool
+	}{
+		{"personnalisé", "Tu es un robot de {{.UserName}}.\n", "Tu es un robot de Brand.\n\n" + speechGuidance, false},
+		{"vide", "", speechGuidance, false},
+		{"variable inconnue", "{{.Inconnue}}", "", true},
+		{"syntaxe", "{{if .UserName}}", "", true},
+	}
+	for _, tt := range tests {
+		setConfig(t, writeTemplate(t, tt.text), "fr-FR", "Brand", 50, 50)
+		p, err := New(nil)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s : pas d'erreur au chargement", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s : %v", tt.name, err)
+		}
+		if prompt, _ := p.SystemPrompt(now); prompt != tt.want {
+			t.Errorf("%s : prompt %q, attendu %q", tt.name, prompt, tt.want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"tars/audio"
+	"tars/audio/convert"
+	"tars/audio/wav"
+	"tars/config"
+	"tars/orchestrator"
+)
+
+// runRecord implémente `tars record` : la source audio passe par le VAD et le segmenteur,
+// comme dans l'assistant, et chaque énoncé détecté est écrit dans son propre fichier WAV.
+// Sert à régler le VAD et à constituer des fixtures pour `tars transcribe` et `tars run -input`.
+func runRecord(args []string) error {
+	fs := newFlagSet("record", "")
+	input := fs.String("input", "", "source audio : mic (micro, par défaut), un fichier .wav, ou - pour du PCM 16 bits brut sur stdin")
+	dir := fs.String("dir", ".", "répertoire des fichiers WAV")
+	count := fs.Int("n", 0, "s'arrête après n énoncés (0 : jusqu'à Ctrl+C ou la fin de la source)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return fmt.Errorf("argument inattendu %q", fs.Arg(0))
+	}
+
+	config.LoadEnv()
+	if *input != "" {
+		config.AudioInput = *input
+	}
+	config.AudioInputRealtime = false // Un fichier est segmenté aussi vite que possible
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return err
+	}
+
+	terminate, err := initPortAudio(true, false)
+	if err != nil {
+		return err
+	}
+	defer terminate()
+
+	ctx, stop := signalContext()
+	defer stop()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	frameChan := make(chan []int16, 50)
+	utteranceChan := make(chan audio.Utterance, 4)
+	source, err := orchestrator.NewSource(frameChan)
+	if err != nil {
+		return fmt.Errorf("création source audio: %w", err)
+	}
+	segmenter, vad, err := orchestrator.NewSegmenter(frameChan, utteranceChan)
+	if err != nil {
+		return err
+	}
+	defer vad.Close()
+
+	sourceErr := make(chan error, 1)
+	go func() { sourceErr <- source.Start(ctx) }()
+	go segmenter.Start(ctx)
+	fmt.Fprintln(os.Stderr, "Enregistrement... Ctrl+C pour arrêter.")
+
+	format := wav.Format{SampleRate: config.SampleRate, Channels: config.Channels, BitsPerSample: 16}
+	prefix := "utterance-" + time.Now().Format("20060102-150405")
+	n := 0
+	for u := range utteranceChan { // Fermé par le segmenteur à l'arrêt ou à la fin de la source
+		if *count > 0 && n >= *count {
+			continue // Le segmenteur peut encore émettre un énoncé après cancel : on vide le canal sans l'écrire
+		}
+		n++
+		path := filepath.Join(*dir, fmt.Sprintf("%s-%03d.wav", prefix, n))
+		data, err := wav.Encode(convert.Int16ToBytes(u.PCM), format)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return err
+		}
+		note := ""
+		if u.Truncated {
+			note = " (coupé à la durée maximale)"
+		}
+		fmt.Printf("%s\t%v%s\n", path, format.Duration(int64(2*len(u.PCM))).Round(10*time.Millisecond), note)
+		if *count > 0 && n >= *count {
+			cancel()
+		}
+	}
+
+	cancel()
+	if err := <-sourceErr; err != nil && !errors.Is(err, context.Canceled) {
+		return fmt.Errorf("source audio: %w", err)
+	}
+	log.Printf("TARS: %d énoncé(s) enregistré(s).", n)
+	return nil
+}
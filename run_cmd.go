@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"tars/config"
+	"tars/orchestrator"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// runAssistant implémente `tars run` (commande par défaut) : le pipeline vocal complet.
+func runAssistant(args []string) error {
+	fs := newFlagSet("run", "")
+	fs.Usage = func() {
+		usage(fs.Output())
+		fmt.Fprintln(fs.Output(), "\nOptions de tars run :")
+		fs.PrintDefaults()
+	}
+	input := fs.String("input", "", "source audio : mic (micro, par défaut), un fichier .wav, ou - pour du PCM 16 bits brut sur stdin")
+	fast := fs.Bool("fast", false, "rejoue le fichier .wav aussi vite que possible au lieu du rythme réel")
+	loop := fs.Bool("loop", false, "rejoue le fichier .wav en boucle")
+	output := fs.String("output", "", "sortie audio : speaker (par défaut), un fichier .wav, ou null")
+	session := fs.String("session", "", "conversation à reprendre : last (par défaut), new, ou l'ID d'une session (voir tars history list)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return fmt.Errorf("argument inattendu %q", fs.Arg(0))
+	}
+
+	log.Println("Démarrage de TARS...")
+	config.LoadConfig()
+	// Les options de la ligne de commande l'emportent sur la config et l'environnement.
+	if *input != "" {
+		config.AudioInput = *input
+	}
+	if *fast {
+		config.AudioInputRealtime = false
+	}
+	if *loop {
+		config.AudioInputLoop = true
+	}
+	if *output != "" {
+		config.AudioOutput = *output
+	}
+	if *session != "" {
+		config.HistorySession = *session
+	}
+
+	terminate, err := initPortAudio(true, true)
+	if err != nil {
+		return err
+	}
+	defer terminate()
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	orch, err := orchestrator.New(openai.NewClient(config.OpenAIAPIKey))
+	if err != nil {
+		return fmt.Errorf("initialisation du pipeline: %w", err)
+	}
+
+	log.Println("TARS est initialisé et à l'écoute. Appuyez sur Ctrl+C pour quitter.")
+	// Run bloque jusqu'à l'annulation du contexte et attend l'arrêt de toutes les étapes.
+	if err := orch.Run(ctx); err != nil {
+		return fmt.Errorf("pipeline arrêté sur erreur: %w", err)
+	}
+	log.Println("TARS: Arrêt terminé.")
+	return nil
+}
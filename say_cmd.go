@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"tars/audio/convert"
+	"tars/config"
+	"tars/orchestrator"
+	"tars/tts"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// runSay implémente `tars say` : le texte passe par le TTS configuré et la même sortie audio
+// que l'assistant (haut-parleurs, fichier WAV ou null). Sans argument, le texte est lu sur stdin.
+func runSay(args []string) error {
+	fs := newFlagSet("say", "[TEXTE...]")
+	output := fs.String("output", "", "sortie audio : speaker (par défaut), un fichier .wav, ou null")
+	voice := fs.String("voice", "", "voix du TTS OpenAI (alloy, echo, fable, onyx, nova, shimmer)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	text := strings.Join(fs.Args(), " ")
+	if text == "" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("lecture de stdin: %w", err)
+		}
+		text = string(data)
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		fs.Usage()
+		return fmt.Errorf("aucun texte à prononcer")
+	}
+
+	config.LoadConfigFor(config.ModuleTTS)
+	if *output != "" {
+		config.AudioOutput = *output
+	}
+	if *voice != "" {
+		config.TTSVoice = *voice
+	}
+
+	terminate, err := initPortAudio(false, true)
+	if err != nil {
+		return err
+	}
+	defer terminate()
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	engine, err := tts.New(openai.NewClient(config.OpenAIAPIKey))
+	if err != nil {
+		return fmt.Errorf("création TTS: %w", err)
+	}
+	pcmChan := make(chan convert.Chunk, 32)
+	player, err := orchestrator.NewSink(pcmChan)
+	if err != nil {
+		return fmt.Errorf("création sortie audio: %w", err)
+	}
+	defer player.Close()
+	go player.StartPlaybackLoop()
+
+	duration := tts.NewTTSProcessor(engine, pcmChan).Process(ctx, text)
+	if duration == 0 && ctx.Err() == nil {
+		return fmt.Errorf("synthèse vocale échouée (voir les logs)")
+	}
+	// Fin de lecture : le PCM a quitté le canal, puis le buffer de sortie se vide.
+	for player.IsPlaying() && ctx.Err() == nil {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if ctx.Err() == nil {
+		time.Sleep(time.Duration(config.PlayerBufferMs) * time.Millisecond)
+	}
+	fmt.Fprintf(os.Stderr, "%v d'audio.\n", duration.Round(10*time.Millisecond))
+	return nil
+}
@@ -0,0 +1,59 @@
+package stt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"tars/audio/wav"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// OpenAISTT transcrit via l'API OpenAI (whisper-1).
+type OpenAISTT struct {
+	client   *openai.Client
+	language string
+}
+
+// NewOpenAISTT crée le backend OpenAI. language ("fr", "en"...) est facultatif : vide, Whisper la détecte.
+func NewOpenAISTT(client *openai.Client, language string) *OpenAISTT {
+	return &OpenAISTT{client: client, language: language}
+}
+
+func (s *OpenAISTT) Name() string { return "openai" }
+
+func (s *OpenAISTT) Transcribe(ctx context.Context, pcm []byte, format Format) (Transcript, error) {
+	// Créer un fichier WAV en mémoire
+	wavData, err := wav.Encode(pcm, wav.Format{SampleRate: format.SampleRate, Channels: format.Channels, BitsPerSample: format.BitDepth})
+	if err != nil {
+		return Transcript{}, fmt.Errorf("création WAV en mémoire: %w", err)
+	}
+
+	req := openai.AudioRequest{
+		Model:    openai.Whisper1,
+		FilePath: "recording.wav",          // Nom de fichier pour l'API, pas un vrai fichier ici
+		Reader:   bytes.NewReader(wavData), // Utilisation de l'io.Reader pour les données en mémoire
+		Language: s.language,
+		Format:   openai.AudioResponseFormatVerboseJSON, // Langue et segments (avg_logprob) en plus du texte
+	}
+
+	resp, err := s.client.CreateTranscription(ctx, req)
+	if err != nil {
+		// TODO: Gérer les erreurs API (limites de taux, etc.)
+		// Si erreur de type *openai.APIError, vous pouvez vérifier resp.Error.HTTPStatusCode
+		return Transcript{}, fmt.Errorf("transcription OpenAI: %w", err)
+	}
+
+	segments := make([]whisperSegment, len(resp.Segments))
+	for i, seg := range resp.Segments {
+		segments[i] = whisperSegment{
+			Start:        seg.Start,
+			End:          seg.End,
+			Text:         seg.Text,
+			AvgLogprob:   seg.AvgLogprob,
+			NoSpeechProb: seg.NoSpeechProb,
+		}
+	}
+	return newTranscript(resp.Text, resp.Language, segments), nil
+}
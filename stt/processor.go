@@ -0,0 +1,47 @@
+package stt
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// STTProcessor est l'étape du pipeline : il transcrit chaque énoncé avec le backend choisi
+// et envoie le texte sur outputChan.
+type STTProcessor struct {
+	engine     STT
+	format     Format
+	outputChan chan string
+}
+
+func NewSTTProcessor(engine STT, format Format, outputChan chan string) *STTProcessor {
+	return &STTProcessor{
+		engine:     engine,
+		format:     format,
+		outputChan: outputChan,
+	}
+}
+
+func (sp *STTProcessor) Process(ctx context.Context, pcmData []byte) {
+	if len(pcmData) == 0 {
+		log.Println("STT: Aucune donnée PCM à traiter.")
+		return
+	}
+
+	log.Printf("STT: Envoi de l'audio à %s...", sp.engine.Name())
+	start := time.Now()
+	transcript, err := sp.engine.Transcribe(ctx, pcmData, sp.format)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Erreur transcription: %v", err)
+		}
+		return
+	}
+
+	log.Printf("STT: Texte reçu en %v (langue: %q, confiance: %.2f): %s",
+		time.Since(start), transcript.Language, transcript.Confidence, transcript.Text)
+	select {
+	case sp.outputChan <- transcript.Text:
+	case <-ctx.Done():
+	}
+}
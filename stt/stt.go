@@ -0,0 +1,113 @@
+// Package stt transcrit les énoncés de l'utilisateur, via OpenAI Whisper ou un serveur whisper.cpp local.
+package stt
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"tars/config"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Format décrit le PCM little-endian passé à Transcribe.
+type Format struct {
+	SampleRate int
+	Channels   int
+	BitDepth   int
+}
+
+// Segment est un morceau de transcription horodaté par rapport au début de l'audio.
+type Segment struct {
+	Start      time.Duration
+	End        time.Duration
+	Text       string
+	Confidence float64 // 0..1, 0 si le backend ne la fournit pas
+}
+
+// Transcript est le résultat d'une transcription.
+type Transcript struct {
+	Text       string
+	Language   string // Code ISO 639-1 ou nom de langue selon le backend, "" si inconnu
+	Segments   []Segment
+	Confidence float64 // Moyenne des segments pondérée par leur durée, 0 si inconnue
+}
+
+// STT est implémenté par chaque backend de transcription.
+type STT interface {
+	// Name identifie le backend dans les logs.
+	Name() string
+	// Transcribe transcrit un énoncé complet.
+	Transcribe(ctx context.Context, pcm []byte, format Format) (Transcript, error)
+}
+
+// Providers acceptés pour config.STTProvider.
+const (
+	ProviderOpenAI     = "openai"
+	ProviderWhisperCpp = "whisper.cpp"
+)
+
+// New crée le backend choisi par config.STTProvider. client n'est utilisé que par le backend OpenAI.
+func New(client *openai.Client) (STT, error) {
+	switch config.Provider(config.STTProvider) {
+	case ProviderOpenAI:
+		if client == nil {
+			return nil, fmt.Errorf("STT OpenAI: client OpenAI manquant")
+		}
+		return NewOpenAISTT(client, config.STTLanguage), nil
+	case ProviderWhisperCpp, "whispercpp", "whisper-cpp":
+		return NewWhisperCppSTT(config.WhisperCppURL, config.STTLanguage), nil
+	default:
+		return nil, fmt.Errorf("STT: provider inconnu %q (attendu %q ou %q)", config.STTProvider, ProviderOpenAI, ProviderWhisperCpp)
+	}
+}
+
+// whisperSegment est le format des segments "verbose_json", commun à l'API OpenAI et à whisper.cpp.
+type whisperSegment struct {
+	Start        float64 `json:"start"`
+	End          float64 `json:"end"`
+	Text         string  `json:"text"`
+	AvgLogprob   float64 `json:"avg_logprob"`
+	NoSpeechProb float64 `json:"no_speech_prob"`
+}
+
+// newTranscript construit un Transcript à partir d'une réponse "verbose_json" (segments optionnels).
+func newTranscript(text, language string, segments []whisperSegment) Transcript {
+	t := Transcript{Text: strings.TrimSpace(text), Language: language}
+
+	var weighted, total float64
+	for _, s := range segments {
+		seg := Segment{
+			Start: secondsToDuration(s.Start),
+			End:   secondsToDuration(s.End),
+			Text:  strings.TrimSpace(s.Text),
+		}
+		if s.AvgLogprob != 0 {
+			// exp(logprob moyen) = probabilité moyenne par token, minorée si le modèle pense que c'est du silence.
+			seg.Confidence = math.Exp(s.AvgLogprob) * (1 - s.NoSpeechProb)
+			seg.Confidence = math.Max(0, math.Min(1, seg.Confidence))
+			d := math.Max(s.End-s.Start, 0.01)
+			weighted += seg.Confidence * d
+			total += d
+		}
+		t.Segments = append(t.Segments, seg)
+	}
+	if total > 0 {
+		t.Confidence = weighted / total
+	}
+	if t.Text == "" && len(t.Segments) > 0 {
+		parts := make([]string, len(t.Segments))
+		for i, s := range t.Segments {
+			parts[i] = s.Text
+		}
+		t.Text = strings.Join(parts, " ")
+	}
+	return t
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,139 @@
+package stt
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tars/audio/convert"
+	"tars/audio/wav"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+var testFormat = Format{SampleRate: 16000, Channels: 1, BitDepth: 16}
+
+// testPCM retourne 0,5 s de PCM 16 bits mono.
+func testPCM() []byte {
+	return convert.Int16ToBytes(make([]int16, 8000))
+}
+
+const verboseJSON = `{"task":"transcribe","language":"fr","duration":1.2,"text":" Bonjour TARS.",` +
+	`"segments":[{"start":0.0,"end":0.6,"text":" Bonjour","avg_logprob":-0.1,"no_speech_prob":0.0},` +
+	`{"start":0.6,"end":1.2,"text":" TARS.","avg_logprob":-0.3,"no_speech_prob":0.5}]}`
+
+// checkTranscript vérifie le résultat du décodage de verboseJSON.
+func checkTranscript(t *testing.T, tr Transcript) {
+	t.Helper()
+	if tr.Text != "Bonjour TARS." || tr.Language != "fr" {
+		t.Errorf("transcription %q (%q), attendu %q (fr)", tr.Text, tr.Language, "Bonjour TARS.")
+	}
+	if len(tr.Segments) != 2 || tr.Segments[1].Start != 600*time.Millisecond || tr.Segments[1].Text != "TARS." {
+		t.Fatalf("segments %+v", tr.Segments)
+	}
+	// Segments de même durée : moyenne simple de exp(logprob) × (1 - no_speech).
+	want := (math.Exp(-0.1) + math.Exp(-0.3)*0.5) / 2
+	if math.Abs(tr.Confidence-want) > 1e-9 {
+		t.Errorf("confiance %v, attendu %v", tr.Confidence, want)
+	}
+}
+
+// whisperServer simule le serveur whisper.cpp et vérifie la requête multipart reçue.
+func whisperServer(t *testing.T, language string, status int, reply string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/inference" {
+			t.Errorf("requête %s %s, attendu POST /inference", r.Method, r.URL.Path)
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("formulaire multipart invalide : %v", err)
+			return
+		}
+		for field, want := range map[string]string{"language": language, "response_format": "verbose_json"} {
+			if got := r.FormValue(field); got != want {
+				t.Errorf("champ %s = %q, attendu %q", field, got, want)
+			}
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("fichier absent : %v", err)
+			return
+		}
+		defer file.Close()
+		wr, err := wav.NewReader(file)
+		if err != nil {
+			t.Errorf("%s : %v", header.Filename, err)
+			return
+		}
+		pcm, _ := io.ReadAll(wr)
+		if f := wr.Format(); f.SampleRate != 16000 || f.Channels != 1 || f.BitsPerSample != 16 || len(pcm) != len(testPCM()) {
+			t.Errorf("WAV %v de %d bytes, attendu 16 kHz mono 16 bits de %d bytes", f, len(pcm), len(testPCM()))
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, reply)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWhisperCppTranscribe(t *testing.T) {
+	for _, tt := range []struct{ language, sent string }{{"fr", "fr"}, {"", "auto"}} {
+		srv := whisperServer(t, tt.sent, http.StatusOK, verboseJSON)
+		tr, err := NewWhisperCppSTT(srv.URL+"/", tt.language).Transcribe(context.Background(), testPCM(), testFormat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkTranscript(t, tr)
+	}
+}
+
+func TestWhisperCppErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		reply  string
+		want   string
+	}{
+		{"HTTP 500", http.StatusInternalServerError, "failed to load model\n", "HTTP 500: failed to load model"},
+		{"erreur JSON", http.StatusOK, `{"error":"audio trop court"}`, "audio trop court"},
+		{"réponse invalide", http.StatusOK, "<html>", "décodage réponse"},
+	}
+	for _, tt := range tests {
+		srv := whisperServer(t, "fr", tt.status, tt.reply)
+		_, err := NewWhisperCppSTT(srv.URL, "fr").Transcribe(context.Background(), testPCM(), testFormat)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s : erreur %v, attendu %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestOpenAITranscribe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/transcriptions" {
+			t.Errorf("chemin %s", r.URL.Path)
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("formulaire multipart invalide : %v", err)
+		}
+		for field, want := range map[string]string{"model": openai.Whisper1, "language": "fr", "response_format": "verbose_json"} {
+			if got := r.FormValue(field); got != want {
+				t.Errorf("champ %s = %q, attendu %q", field, got, want)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, verboseJSON)
+	}))
+	defer srv.Close()
+
+	cfg := openai.DefaultConfig("test")
+	cfg.BaseURL = srv.URL + "/v1"
+	tr, err := NewOpenAISTT(openai.NewClientWithConfig(cfg), "fr").Transcribe(context.Background(), testPCM(), testFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTranscript(t, tr)
+}
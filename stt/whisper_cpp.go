@@ -0,0 +1,107 @@
+package stt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"tars/audio/wav"
+)
+
+// WhisperCppSTT transcrit via le serveur HTTP de whisper.cpp (examples/server), lancé en local par ex. :
+//
+//	./whisper-server -m models/ggml-base.bin --host 127.0.0.1 --port 8080
+type WhisperCppSTT struct {
+	baseURL    string
+	language   string
+	httpClient *http.Client
+}
+
+// NewWhisperCppSTT crée le backend whisper.cpp. baseURL est l'adresse du serveur (ex. http://127.0.0.1:8080) ;
+// language vide laisse le serveur détecter la langue.
+func NewWhisperCppSTT(baseURL, language string) *WhisperCppSTT {
+	return &WhisperCppSTT{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		language:   language,
+		httpClient: &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+func (s *WhisperCppSTT) Name() string { return "whisper.cpp" }
+
+// whisperCppResponse couvre "verbose_json" (serveurs récents) et "json" (texte seul).
+type whisperCppResponse struct {
+	Text     string           `json:"text"`
+	Language string           `json:"language"`
+	Segments []whisperSegment `json:"segments"`
+	Error    string           `json:"error"`
+}
+
+func (s *WhisperCppSTT) Transcribe(ctx context.Context, pcm []byte, format Format) (Transcript, error) {
+	wavData, err := wav.Encode(pcm, wav.Format{SampleRate: format.SampleRate, Channels: format.Channels, BitsPerSample: format.BitDepth})
+	if err != nil {
+		return Transcript{}, fmt.Errorf("création WAV en mémoire: %w", err)
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("file", "recording.wav")
+	if err != nil {
+		return Transcript{}, err
+	}
+	if _, err := file.Write(wavData); err != nil {
+		return Transcript{}, err
+	}
+	language := s.language
+	if language == "" {
+		language = "auto" // Le serveur utilise "en" par défaut
+	}
+	fields := map[string]string{
+		"response_format": "verbose_json",
+		"language":        language,
+		"temperature":     "0.0",
+	}
+	for k, v := range fields {
+		if err := form.WriteField(k, v); err != nil {
+			return Transcript{}, err
+		}
+	}
+	if err := form.Close(); err != nil {
+		return Transcript{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/inference", &body)
+	if err != nil {
+		return Transcript{}, err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return Transcript{}, fmt.Errorf("requête whisper.cpp (%s): %w", s.baseURL, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Transcript{}, fmt.Errorf("lecture réponse whisper.cpp: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Transcript{}, fmt.Errorf("whisper.cpp: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var out whisperCppResponse
+	if err := json.Unmarshal(data, &out); err != nil {
+		return Transcript{}, fmt.Errorf("décodage réponse whisper.cpp: %w", err)
+	}
+	if out.Error != "" {
+		return Transcript{}, fmt.Errorf("whisper.cpp: %s", out.Error)
+	}
+	return newTranscript(out.Text, out.Language, out.Segments), nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"tars/audio"
+	"tars/audio/convert"
+	"tars/audio/wav"
+	"tars/config"
+	"tars/orchestrator"
+	"tars/stt"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// runTranscribe implémente `tars transcribe` : des fichiers WAV (tout format) passés au STT
+// configuré, en entier ou énoncé par énoncé après découpe par le VAD comme dans l'assistant.
+func runTranscribe(args []string) error {
+	fs := newFlagSet("transcribe", "FICHIER.wav...")
+	segment := fs.Bool("segment", false, "découpe d'abord le fichier en énoncés avec le VAD, comme l'assistant")
+	details := fs.Bool("details", false, "affiche la langue, la confiance et les segments horodatés")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("aucun fichier à transcrire")
+	}
+
+	config.LoadConfigFor(config.ModuleSTT)
+	engine, err := stt.New(openai.NewClient(config.OpenAIAPIKey))
+	if err != nil {
+		return fmt.Errorf("création STT: %w", err)
+	}
+	format := stt.Format{SampleRate: config.SampleRate, Channels: config.Channels, BitDepth: config.BitDepth}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	for _, path := range fs.Args() {
+		if fs.NArg() > 1 {
+			fmt.Printf("%s :\n", path)
+		}
+		if *segment {
+			err = transcribeUtterances(ctx, engine, format, path, *details)
+		} else {
+			err = transcribeFile(ctx, engine, format, path, *details)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// transcribeFile transcrit le fichier entier, converti au format du pipeline.
+func transcribeFile(ctx context.Context, engine stt.STT, format stt.Format, path string, details bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r, err := wav.NewReader(f)
+	if err != nil {
+		return err
+	}
+	pcm, from := r.PCM()
+	data, err := io.ReadAll(pcm)
+	if err != nil {
+		return err
+	}
+	to := convert.Format{SampleRate: format.SampleRate, Channels: format.Channels, Sample: convert.Int16}
+	conv := convert.NewConverter(from, to)
+	data = append(conv.Convert(data), conv.Flush()...)
+
+	transcript, err := engine.Transcribe(ctx, data, format)
+	if err != nil {
+		return err
+	}
+	printTranscript(transcript, "", details)
+	return nil
+}
+
+// transcribeUtterances fait passer le fichier par le VAD et le segmenteur, puis transcrit
+// chaque énoncé détecté.
+func transcribeUtterances(ctx context.Context, engine stt.STT, format stt.Format, path string, details bool) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	frameChan := make(chan []int16, 50)
+	utteranceChan := make(chan audio.Utterance, 4)
+	source, err := audio.NewWAVFileSource(path, config.SampleRate, config.VADFrameDurationMs, false, false, frameChan)
+	if err != nil {
+		return err
+	}
+	segmenter, vad, err := orchestrator.NewSegmenter(frameChan, utteranceChan)
+	if err != nil {
+		return err
+	}
+	defer vad.Close()
+
+	sourceErr := make(chan error, 1)
+	go func() { sourceErr <- source.Start(ctx) }()
+	go segmenter.Start(ctx)
+
+	n := 0
+	for u := range utteranceChan {
+		n++
+		transcript, err := engine.Transcribe(ctx, convert.Int16ToBytes(u.PCM), format)
+		if err != nil {
+			return err
+		}
+		// Durée d'après le PCM : le fichier est lu plus vite que le temps réel.
+		duration := time.Duration(len(u.PCM)) * time.Second / time.Duration(config.SampleRate)
+		printTranscript(transcript, fmt.Sprintf("[%d] (%v) ", n, duration.Round(10*time.Millisecond)), details)
+	}
+	if err := <-sourceErr; err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if n == 0 {
+		fmt.Println("(aucune parole détectée)")
+	}
+	return nil
+}
+
+func printTranscript(t stt.Transcript, prefix string, details bool) {
+	fmt.Println(prefix + t.Text)
+	if !details {
+		return
+	}
+	fmt.Printf("    langue : %q, confiance : %.2f\n", t.Language, t.Confidence)
+	for _, seg := range t.Segments {
+		fmt.Printf("    %8v → %8v  %s\n", seg.Start.Round(10*time.Millisecond), seg.End.Round(10*time.Millisecond), seg.Text)
+	}
+}
@@ -0,0 +1,66 @@
+package tts
+
+import (
+	"context"
+	"fmt"
+
+	"tars/audio/convert"
+	"tars/audio/decode"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// openAIFormat est le format du PCM renvoyé par l'API OpenAI TTS (SpeechResponseFormatPcm).
+var openAIFormat = convert.Format{SampleRate: 24000, Channels: 1, Sample: convert.Int16}
+
+// OpenAITTS synthétise via l'API OpenAI.
+type OpenAITTS struct {
+	client *openai.Client
+	voice  openai.SpeechVoice
+	codec  decode.Codec
+}
+
+// NewOpenAITTS crée le backend OpenAI. voice vide : alloy. codec choisit le format de réponse :
+// "pcm" a la plus faible latence, "mp3" et "opus" réduisent la bande passante (décodés localement).
+func NewOpenAITTS(client *openai.Client, voice string, codec decode.Codec) *OpenAITTS {
+	if voice == "" {
+		voice = string(openai.VoiceAlloy)
+	}
+	if codec == "" {
+		codec = decode.PCM
+	}
+	return &OpenAITTS{client: client, voice: openai.SpeechVoice(voice), codec: codec}
+}
+
+func (t *OpenAITTS) Name() string { return "openai/" + string(t.voice) + "/" + string(t.codec) }
+
+// Formats de réponse OpenAI correspondant aux codecs décodables.
+var openAIResponseFormats = map[decode.Codec]openai.SpeechResponseFormat{
+	decode.PCM:  openai.SpeechResponseFormatPcm,
+	decode.MP3:  openai.SpeechResponseFormatMp3,
+	decode.Opus: openai.SpeechResponseFormatOpus,
+	decode.WAV:  openai.SpeechResponseFormatWav,
+}
+
+func (t *OpenAITTS) Synthesize(ctx context.Context, text string) (*Audio, error) {
+	req := openai.CreateSpeechRequest{
+		Model:          openai.TTSModel1, // ou TTSModel1HD
+		Input:          text,
+		Voice:          t.voice, // alloy, echo, fable, onyx, nova, shimmer
+		ResponseFormat: openAIResponseFormats[t.codec],
+		// Speed: 1.0, // Facteur de vitesse
+	}
+
+	audioStream, err := t.client.CreateSpeech(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("création speech OpenAI: %w", err)
+	}
+
+	// Le décodage se fait au fil de la réception : la lecture démarre avant la fin du téléchargement.
+	pcm, format, err := decode.NewReader(t.codec, audioStream, openAIFormat)
+	if err != nil {
+		audioStream.Close()
+		return nil, fmt.Errorf("décodage réponse OpenAI (%s): %w", t.codec, err)
+	}
+	return &Audio{ReadCloser: readCloser{Reader: pcm, Closer: audioStream}, Format: format}, nil
+}
@@ -0,0 +1,127 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"tars/audio/convert"
+)
+
+// PiperTTS synthétise en local avec le binaire piper (https://github.com/rhasspy/piper) :
+// le texte est écrit sur son stdin et le PCM brut (16 bits mono) lu sur son stdout.
+// Un processus est lancé par phrase ; le chargement du modèle ajoute donc un peu de latence.
+type PiperTTS struct {
+	binary  string
+	model   string
+	speaker int // -1 : locuteur par défaut du modèle
+	format  convert.Format
+}
+
+// piperModelConfig est la partie utile du fichier <modèle>.onnx.json livré avec chaque voix.
+type piperModelConfig struct {
+	Audio struct {
+		SampleRate int `json:"sample_rate"`
+	} `json:"audio"`
+}
+
+// NewPiperTTS crée le backend Piper. model est le chemin du .onnx ; le sample rate est lu
+// dans le .onnx.json qui l'accompagne.
+func NewPiperTTS(binary, model string, speaker int) (*PiperTTS, error) {
+	if model == "" {
+		return nil, errors.New("TTS Piper: PiperModel doit être défini (chemin du .onnx)")
+	}
+	if binary == "" {
+		binary = "piper"
+	}
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return nil, fmt.Errorf("TTS Piper: binaire %q introuvable: %w", binary, err)
+	}
+
+	data, err := os.ReadFile(model + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("TTS Piper: lecture de la config du modèle: %w", err)
+	}
+	var cfg piperModelConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("TTS Piper: config du modèle invalide: %w", err)
+	}
+	if cfg.Audio.SampleRate <= 0 {
+		return nil, fmt.Errorf("TTS Piper: sample_rate absent de %s.json", model)
+	}
+
+	return &PiperTTS{
+		binary:  path,
+		model:   model,
+		speaker: speaker,
+		format:  convert.Format{SampleRate: cfg.Audio.SampleRate, Channels: 1, Sample: convert.Int16},
+	}, nil
+}
+
+func (t *PiperTTS) Name() string { return "piper/" + t.model }
+
+func (t *PiperTTS) Synthesize(ctx context.Context, text string) (*Audio, error) {
+	args := []string{"--model", t.model, "--output-raw", "--quiet"}
+	if t.speaker >= 0 {
+		args = append(args, "--speaker", strconv.Itoa(t.speaker))
+	}
+
+	cmd := exec.CommandContext(ctx, t.binary, args...)
+	// Piper lit une phrase par ligne : les retours à la ligne du texte produiraient des pauses.
+	cmd.Stdin = strings.NewReader(strings.Join(strings.Fields(text), " ") + "\n")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("lancement de piper: %w", err)
+	}
+	return &Audio{ReadCloser: &piperStream{ctx: ctx, stdout: stdout, cmd: cmd, stderr: &stderr}, Format: t.format}, nil
+}
+
+// piperStream lit le stdout de piper et attend la fin du processus à la fermeture.
+type piperStream struct {
+	ctx    context.Context
+	stdout io.ReadCloser
+	cmd    *exec.Cmd
+	stderr *bytes.Buffer
+	eof    bool // stdout lu jusqu'au bout : piper a fini (ou s'est arrêté) de lui-même
+}
+
+func (s *piperStream) Read(p []byte) (int, error) {
+	n, err := s.stdout.Read(p)
+	if err == io.EOF {
+		s.eof = true
+	}
+	return n, err
+}
+
+// Close arrête piper si la lecture a été abandonnée avant la fin de son stdout ; sinon, attend
+// sa sortie et remonte son éventuelle erreur avec son stderr.
+func (s *piperStream) Close() error {
+	if !s.eof {
+		s.stdout.Close()
+		s.cmd.Process.Kill()
+		s.cmd.Wait()
+		return nil // Arrêté par nous : son code de sortie n'a pas d'intérêt
+	}
+	err := s.cmd.Wait()
+	if err != nil && s.ctx.Err() != nil {
+		return nil // Tué par l'annulation du contexte
+	}
+	if err != nil {
+		return fmt.Errorf("piper: %w: %s", err, strings.TrimSpace(s.stderr.String()))
+	}
+	return nil
+}
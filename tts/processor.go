@@ -0,0 +1,111 @@
+package tts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"tars/audio/convert"
+	"tars/config"
+)
+
+// TTSProcessor est l'étape du pipeline : il synthétise chaque morceau de réponse avec le backend
+// choisi et envoie le PCM au player, tagué avec son format (le player convertit si besoin).
+type TTSProcessor struct {
+	engine     TTS
+	outputChan chan convert.Chunk // Chan de morceaux PCM
+}
+
+func NewTTSProcessor(engine TTS, outputChan chan convert.Chunk) *TTSProcessor {
+	return &TTSProcessor{
+		engine:     engine,
+		outputChan: outputChan,
+	}
+}
+
+// Process synthétise text et envoie le PCM au player au fur et à mesure de la synthèse.
+// Retourne la durée d'audio effectivement envoyée (partielle si ctx est annulé en cours de route).
+func (tp *TTSProcessor) Process(ctx context.Context, text string) time.Duration {
+	if text == "" {
+		log.Println("TTS: Texte vide, rien à synthétiser.")
+		return 0
+	}
+
+	log.Printf("TTS: Demande de synthèse vocale (%s) pour: \"%s\"", tp.engine.Name(), text)
+	start := time.Now()
+	audio, err := tp.engine.Synthesize(ctx, text)
+	if err != nil {
+		log.Printf("Erreur synthèse vocale: %v", err)
+		return 0
+	}
+	defer func() {
+		if err := audio.Close(); err != nil && ctx.Err() == nil {
+			log.Printf("TTS: Erreur à la fermeture du flux audio: %v", err)
+		}
+	}()
+
+	sent, err := tp.stream(ctx, audio, start)
+	if err != nil && ctx.Err() == nil {
+		log.Printf("Erreur lecture stream audio: %v", err)
+	}
+	duration := audio.Format.Duration(sent)
+	if ctx.Err() != nil {
+		log.Printf("TTS: Synthèse interrompue après %v d'audio envoyé.", duration)
+	} else {
+		log.Printf("TTS: %v d'audio (%v) envoyé au player en %v.", duration, audio.Format, time.Since(start))
+	}
+	return duration
+}
+
+// stream envoie le PCM au player au fil de sa réception, par morceaux d'au plus config.TTSChunkMs,
+// sans jamais couper une frame (tous les canaux d'un échantillon). Retourne les bytes envoyés.
+func (tp *TTSProcessor) stream(ctx context.Context, audio *Audio, start time.Time) (int, error) {
+	frame := audio.Format.BytesPerFrame()
+	if frame <= 0 {
+		return 0, fmt.Errorf("format audio invalide: %v", audio.Format)
+	}
+	chunkBytes := audio.Format.SampleRate * frame * config.TTSChunkMs / 1000
+	chunkBytes = max(chunkBytes-chunkBytes%frame, frame)
+
+	readBuf := make([]byte, chunkBytes)
+	var carry []byte // Début de frame incomplète, complété par la lecture suivante
+	sent := 0
+	first := true
+	for {
+		n, err := audio.Read(readBuf)
+		if n > 0 {
+			if first {
+				log.Printf("TTS: Premier octet reçu en %v.", time.Since(start))
+				first = false
+			}
+			data := append(carry, readBuf[:n]...)
+			aligned := len(data) - len(data)%frame
+			carry = append([]byte(nil), data[aligned:]...)
+			if aligned > 0 {
+				chunk := make([]byte, aligned) // Le player garde le slice : pas de réutilisation du buffer
+				copy(chunk, data[:aligned])
+				select {
+				case tp.outputChan <- convert.Chunk{Data: chunk, Format: audio.Format}:
+					sent += aligned
+				case <-ctx.Done():
+					return sent, ctx.Err()
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			if len(carry) > 0 {
+				log.Printf("TTS: %d bytes en fin de flux ignorés (frame incomplète).", len(carry))
+			}
+			return sent, nil
+		}
+		if err != nil {
+			return sent, err
+		}
+		if ctx.Err() != nil { // Interrompu pendant la synthèse
+			return sent, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"tars/audio/convert"
+)
+
+// oddReader rend des lectures de 1, 3, 5, 7... bytes : les échantillons sont coupés entre deux lectures.
+type oddReader struct {
+	r     io.Reader
+	reads int
+}
+
+func (o *oddReader) Read(p []byte) (int, error) {
+	n := 2*(o.reads%7) + 1 // 1, 3, 5... 13, puis on recommence
+	o.reads++
+	return o.r.Read(p[:min(len(p), n)])
+}
+
+// fakeEngine sert un flux audio préparé à l'avance.
+type fakeEngine struct {
+	reader func() io.Reader
+	format convert.Format
+}
+
+func (f *fakeEngine) Name() string { return "test" }
+
+func (f *fakeEngine) Synthesize(context.Context, string) (*Audio, error) {
+	return &Audio{ReadCloser: io.NopCloser(f.reader()), Format: f.format}, nil
+}
+
+func TestProcessFrameAlignment(t *testing.T) {
+	formats := []convert.Format{
+		{SampleRate: 24000, Channels: 1, Sample: convert.Int16},   // Frames de 2 bytes
+		{SampleRate: 48000, Channels: 2, Sample: convert.Int16},   // 4 bytes
+		{SampleRate: 22050, Channels: 2, Sample: convert.Float32}, // 8 bytes
+	}
+	for _, format := range formats {
+		frame := format.BytesPerFrame()
+		data := make([]byte, 1000*frame+frame-1) // Frame incomplète en fin de flux
+		for i := range data {
+			data[i] = byte(i * 7)
+		}
+		readers := map[string]func() io.Reader{
+			"impair":     func() io.Reader { return &oddReader{r: bytes.NewReader(data)} },
+			"octet":      func() io.Reader { return iotest.OneByteReader(bytes.NewReader(data)) },
+			"avec fin":   func() io.Reader { return iotest.DataErrReader(&oddReader{r: bytes.NewReader(data)}) },
+			"en un bloc": func() io.Reader { return bytes.NewReader(data) },
+		}
+		for name, reader := range readers {
+			out := make(chan convert.Chunk, len(data))
+			tp := NewTTSProcessor(&fakeEngine{reader: reader, format: format}, out)
+			duration := tp.Process(context.Background(), "Bonjour.")
+			close(out)
+
+			var got []byte
+			for chunk := range out {
+				if len(chunk.Data)%frame != 0 || chunk.Format != format {
+					t.Errorf("%v, %s : morceau de %d bytes (%v), attendu des frames de %d bytes", format, name, len(chunk.Data), chunk.Format, frame)
+				}
+				got = append(got, chunk.Data...)
+			}
+			if want := data[:1000*frame]; !bytes.Equal(got, want) {
+				t.Errorf("%v, %s : %d bytes reçus, attendu les %d bytes des frames complètes, dans l'ordre", format, name, len(got), len(want))
+			}
+			if want := format.Duration(1000 * frame); duration != want {
+				t.Errorf("%v, %s : durée %v, attendu %v", format, name, duration, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// Package tts synthétise les réponses du bot, via OpenAI ou un binaire Piper local.
+package tts
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"tars/audio/convert"
+	"tars/audio/decode"
+	"tars/config"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Audio est le résultat d'une synthèse : un flux PCM à lire au fur et à mesure, et son format.
+// Close doit toujours être appelé (il libère la connexion HTTP ou le processus).
+type Audio struct {
+	io.ReadCloser
+	Format convert.Format
+}
+
+// readCloser associe un lecteur (ex. décodeur) au Close du flux sous-jacent.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// TTS est implémenté par chaque backend de synthèse vocale.
+type TTS interface {
+	// Name identifie le backend dans les logs.
+	Name() string
+	// Synthesize démarre la synthèse de text. Annuler ctx interrompt la synthèse en cours.
+	Synthesize(ctx context.Context, text string) (*Audio, error)
+}
+
+// Providers acceptés pour config.TTSProvider.
+const (
+	ProviderOpenAI = "openai"
+	ProviderPiper  = "piper"
+)
+
+// New crée le backend choisi par config.TTSProvider. client n'est utilisé que par le backend OpenAI.
+func New(client *openai.Client) (TTS, error) {
+	switch config.Provider(config.TTSProvider) {
+	case ProviderOpenAI:
+		if client == nil {
+			return nil, fmt.Errorf("TTS OpenAI: client OpenAI manquant")
+		}
+		codec, err := decode.ParseCodec(config.OpenAITTSFormat)
+		if err != nil {
+			return nil, fmt.Errorf("TTS OpenAI: %w", err)
+		}
+		return NewOpenAITTS(client, config.TTSVoice, codec), nil
+	case ProviderPiper:
+		return NewPiperTTS(config.PiperPath, config.PiperModel, config.PiperSpeaker)
+	default:
+		return nil, fmt.Errorf("TTS: provider inconnu %q (attendu %q ou %q)", config.TTSProvider, ProviderOpenAI, ProviderPiper)
+	}
+}